@@ -0,0 +1,160 @@
+package main
+
+import (
+	"calculator/internal/coin"
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"calculator/internal/roster"
+	"flag"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// commands 命令行子命令，用法: go run . <命令> [参数]
+var commands = map[string]func(args []string) error{
+	"import-roster": importRosterCommand,
+	"create-admin":  createAdminCommand,
+	"verify-coins":  verifyCoinsCommand,
+}
+
+// runCommand 执行命令行子命令
+func runCommand(args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("未知命令: %s", args[0])
+	}
+	return cmd(args[1:])
+}
+
+// createAdminCommand 创建管理员账号，管理员无法通过注册接口创建
+func createAdminCommand(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	username := fs.String("username", "", "管理员用户名")
+	password := fs.String("password", "", "管理员密码")
+	fs.Parse(args)
+
+	if *username == "" || len(*password) < 6 {
+		fs.Usage()
+		return fmt.Errorf("必须指定 -username 和至少 6 位的 -password")
+	}
+
+	var count int64
+	if err := database.DB.Model(&model.User{}).Where("username = ?", *username).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("用户名已存在: %s", *username)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %v", err)
+	}
+
+	admin := model.User{
+		Username: *username,
+		Password: string(hashedPassword),
+		Role:     model.RoleAdmin,
+	}
+	if err := database.DB.Create(&admin).Error; err != nil {
+		return fmt.Errorf("创建管理员失败: %v", err)
+	}
+
+	fmt.Printf("管理员 %s 创建成功\n", *username)
+	return nil
+}
+
+// importRosterCommand 从 CSV/XLSX 导入学生名单，并输出可打印的凭证条
+func importRosterCommand(args []string) error {
+	fs := flag.NewFlagSet("import-roster", flag.ExitOnError)
+	file := fs.String("file", "", "名单文件路径（.csv 或 .xlsx）")
+	teacher := fs.String("teacher", "", "名单所属教师的用户名")
+	out := fs.String("out", "slips.html", "凭证条输出路径（HTML）")
+	fs.Parse(args)
+
+	if *file == "" || *teacher == "" {
+		fs.Usage()
+		return fmt.Errorf("必须指定 -file 和 -teacher")
+	}
+
+	var teacherUser model.User
+	if err := database.DB.Where("username = ? AND role = ?", *teacher, model.RoleTeacher).First(&teacherUser).Error; err != nil {
+		return fmt.Errorf("教师不存在: %s", *teacher)
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("读取名单文件失败: %v", err)
+	}
+
+	rows, err := roster.Parse(*file, data)
+	if err != nil {
+		return err
+	}
+
+	slips, rowErrs, err := roster.Import(database.DB, teacherUser.ID, rows)
+	if err != nil {
+		return err
+	}
+	if len(rowErrs) > 0 {
+		for _, rowErr := range rowErrs {
+			fmt.Fprintf(os.Stderr, "第 %d 行: %s\n", rowErr.Line, rowErr.Message)
+		}
+		return fmt.Errorf("名单校验失败，未导入任何学生")
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return fmt.Errorf("创建凭证条文件失败: %v", err)
+	}
+	defer f.Close()
+
+	if err := roster.RenderSlips(f, slips); err != nil {
+		return fmt.Errorf("生成凭证条失败: %v", err)
+	}
+
+	fmt.Printf("成功导入 %d 名学生，凭证条已写入 %s\n", len(slips), *out)
+	return nil
+}
+
+// verifyCoinsCommand 核对所有用户的金币余额与流水，发现不一致时返回错误
+func verifyCoinsCommand(args []string) error {
+	fs := flag.NewFlagSet("verify-coins", flag.ExitOnError)
+	fs.Parse(args)
+
+	var walletUsers, ledgerUsers []uint
+	if err := database.DB.Model(&model.CoinWallet{}).Pluck("user_id", &walletUsers).Error; err != nil {
+		return err
+	}
+	if err := database.DB.Model(&model.CoinTransaction{}).Distinct().Pluck("user_id", &ledgerUsers).Error; err != nil {
+		return err
+	}
+
+	seen := make(map[uint]bool)
+	inconsistent := 0
+	for _, userID := range append(walletUsers, ledgerUsers...) {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		audit, err := coin.Verify(database.DB, userID)
+		if err != nil {
+			return err
+		}
+		if !audit.Consistent {
+			inconsistent++
+			for _, problem := range audit.Problems {
+				fmt.Fprintf(os.Stderr, "用户 %d: %s\n", userID, problem)
+			}
+		}
+	}
+
+	if inconsistent > 0 {
+		return fmt.Errorf("%d 个用户的金币账目不一致", inconsistent)
+	}
+	fmt.Printf("已核对 %d 个用户的金币账目，全部一致\n", len(seen))
+	return nil
+}
@@ -45,6 +45,7 @@ document.addEventListener('DOMContentLoaded', () => {
 
     // 热度排行榜相关函数
     let currentRankType = 'hourly'; // 默认显示小时榜
+    let rankingStream = null; // 排行榜实时推送连接
 
     function showHotRankModal() {
         hotRankModal.style.display = 'block';
@@ -53,6 +54,59 @@ document.addEventListener('DOMContentLoaded', () => {
 
     function closeHotRankModal() {
         hotRankModal.style.display = 'none';
+        closeRankingStream();
+    }
+
+    function closeRankingStream() {
+        if (rankingStream) {
+            rankingStream.close();
+            rankingStream = null;
+        }
+    }
+
+    // 订阅排行榜实时推送，浏览器不支持时只加载一次
+    // URL 中只带一次性票据，连接断开后重新获取票据再连接
+    async function openRankingStream(type) {
+        closeRankingStream();
+        if (!window.EventSource) {
+            return;
+        }
+        const { ticket } = await apiRequest('/api/drill/rankings/stream/ticket', { method: 'POST' });
+        if (hotRankModal.style.display !== 'block' || currentRankType !== type) {
+            return;
+        }
+        const stream = new EventSource(`/api/drill/rankings/stream?type=${type}&ticket=${encodeURIComponent(ticket)}`);
+        stream.addEventListener('rankings', (e) => {
+            renderRankings(JSON.parse(e.data).rankings);
+        });
+        stream.addEventListener('error', () => {
+            if (stream === rankingStream && stream.readyState === EventSource.CLOSED) {
+                rankingStream = null;
+                setTimeout(() => {
+                    if (!rankingStream && hotRankModal.style.display === 'block') {
+                        openRankingStream(currentRankType).catch((error) => console.error('订阅排行榜失败:', error));
+                    }
+                }, 5000);
+            }
+        });
+        rankingStream = stream;
+    }
+
+    function renderRankings(rankings) {
+        // 清空现有数据
+        const tbody = document.getElementById('rankingTableBody');
+        tbody.innerHTML = '';
+
+        // 添加新数据
+        rankings.forEach(rank => {
+            const row = document.createElement('tr');
+            row.innerHTML = `
+                <td>${rank.rank}</td>
+                <td>${rank.username}</td>
+                <td style="text-align: center;">${rank.hot_score.toFixed(1)}</td>
+            `;
+            tbody.appendChild(row);
+        });
     }
 
     function switchTab(type) {
@@ -74,26 +128,11 @@ document.addEventListener('DOMContentLoaded', () => {
             }
 
             const data = await apiRequest(`/api/drill/rankings?type=${type}`, {
-                method: 'GET',
-                headers: {
-                    'Authorization': `Bearer ${token}`
-                }
+                method: 'GET'
             });
 
-            // 清空现有数据
-            const tbody = document.getElementById('rankingTableBody');
-            tbody.innerHTML = '';
-
-            // 添加新数据
-            data.rankings.forEach(rank => {
-                const row = document.createElement('tr');
-                row.innerHTML = `
-                    <td>${rank.rank}</td>
-                    <td>${rank.username}</td>
-                    <td style="text-align: center;">${rank.hot_score.toFixed(1)}</td>
-                `;
-                tbody.appendChild(row);
-            });
+            renderRankings(data.rankings);
+            openRankingStream(type).catch((error) => console.error('订阅排行榜失败:', error));
         } catch (error) {
             console.error('获取排行榜失败:', error);
             alert(error.message || '获取排行榜失败，请重试');
@@ -113,8 +152,47 @@ document.addEventListener('DOMContentLoaded', () => {
         loginForm.style.display = 'block';
     });
 
-    // 通用API请求函数
-    async function apiRequest(url, options = {}) {
+    // 正在进行的令牌刷新，多个请求同时过期时共用一次刷新
+    let refreshPromise = null;
+
+    // 使用刷新令牌换取新的访问令牌，失败时返回 false；expiredToken 为过期的访问令牌
+    // 刷新令牌只能使用一次：同一页面内的并发请求共用一次刷新，
+    // 多个标签页通过 Web Locks 依次刷新，拿到锁时其他标签页已经刷新过则直接使用新令牌
+    function refreshAccessToken(expiredToken) {
+        if (!refreshPromise) {
+            const refresh = () => requestNewTokens(expiredToken);
+            const pending = navigator.locks ? navigator.locks.request('refresh-token', refresh) : refresh();
+            refreshPromise = pending.finally(() => {
+                refreshPromise = null;
+            });
+        }
+        return refreshPromise;
+    }
+
+    async function requestNewTokens(expiredToken) {
+        if (localStorage.getItem('token') !== expiredToken) {
+            return true;
+        }
+        const refreshToken = localStorage.getItem('refresh_token');
+        if (!refreshToken) {
+            return false;
+        }
+        const response = await fetch('/api/auth/refresh', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ refresh_token: refreshToken }),
+        });
+        if (!response.ok) {
+            return false;
+        }
+        const data = await response.json();
+        localStorage.setItem('token', data.token);
+        localStorage.setItem('refresh_token', data.refresh_token);
+        return true;
+    }
+
+    // 通用API请求函数，访问令牌过期时自动刷新并重试一次
+    async function apiRequest(url, options = {}, retried = false) {
         try {
             // 确保 URL 以 / 开头
             const apiUrl = url.startsWith('/') ? url : `/${url}`;
@@ -143,8 +221,11 @@ document.addEventListener('DOMContentLoaded', () => {
             console.log('响应数据:', data);
 
             if (!response.ok) {
-                // 处理401未授权
+                // 处理401未授权：先尝试刷新令牌，刷新失败才需要重新登录
                 if (response.status === 401) {
+                    if (!retried && await refreshAccessToken(token)) {
+                        return apiRequest(url, options, true);
+                    }
                     clearAuthData();
                     appContainer.style.display = 'none';
                     authContainer.style.display = 'block';
@@ -187,6 +268,7 @@ document.addEventListener('DOMContentLoaded', () => {
             if (response.ok && data.token) {
                 // 保存token
                 localStorage.setItem('token', data.token);
+                localStorage.setItem('refresh_token', data.refresh_token);
                 // 保存用户名
                 localStorage.setItem('username', username);
                 // 显示用户名
@@ -209,6 +291,7 @@ document.addEventListener('DOMContentLoaded', () => {
         const password = registerPassword.value.trim();
         const confirmPassword = registerConfirmPassword.value.trim();
         const role = document.getElementById('register-role').value;
+        const code = document.getElementById('register-code').value.trim();
 
         // 移除之前的错误提示
         const oldError = registerForm.querySelector('.error-message');
@@ -243,7 +326,7 @@ document.addEventListener('DOMContentLoaded', () => {
         try {
             await apiRequest('/api/auth/register', {
                 method: 'POST',
-                body: JSON.stringify({ username, password, role })
+                body: JSON.stringify({ username, password, role, code })
             });
 
             // 注册成功后切换到登录表单
@@ -282,16 +365,20 @@ document.addEventListener('DOMContentLoaded', () => {
     // 登出功能
     logoutBtn.addEventListener('click', async () => {
         try {
-            const response = await fetch('/api/auth/logout', {
+            // 没有刷新令牌时只需清除本地登录状态
+            const refreshToken = localStorage.getItem('refresh_token');
+            const response = refreshToken ? await fetch('/api/auth/logout', {
                 method: 'POST',
                 headers: {
-                    'Authorization': `Bearer ${localStorage.getItem('token')}`,
+                    'Content-Type': 'application/json',
                 },
-            });
+                body: JSON.stringify({ refresh_token: refreshToken }),
+            }) : null;
 
-            if (response.ok) {
+            if (!response || response.ok) {
                 // 清除本地存储
                 localStorage.removeItem('token');
+                localStorage.removeItem('refresh_token');
                 localStorage.removeItem('username');
                 // 切换回登录界面
                 appContainer.style.display = 'none';
@@ -414,6 +501,7 @@ document.addEventListener('DOMContentLoaded', () => {
     // 清除认证数据
     function clearAuthData() {
         localStorage.removeItem('token');
+        localStorage.removeItem('refresh_token');
         localStorage.removeItem('username');
         currentUser = null;
     }
@@ -51,6 +51,7 @@ document.getElementById('login').addEventListener('submit', async (e) => {
 
         // 登录成功
         localStorage.setItem('token', data.token);
+        localStorage.setItem('refresh_token', data.refresh_token);
         localStorage.setItem('username', data.username);
         localStorage.setItem('role', data.role);
         
@@ -71,6 +72,7 @@ document.getElementById('register').addEventListener('submit', async (e) => {
     const password = document.getElementById('register-password').value;
     const confirmPassword = document.getElementById('register-confirm-password').value;
     const role = document.getElementById('register-role').value;
+    const code = document.getElementById('register-code').value.trim();
 
     // 验证密码
     if (password !== confirmPassword) {
@@ -84,7 +86,7 @@ document.getElementById('register').addEventListener('submit', async (e) => {
             headers: {
                 'Content-Type': 'application/json',
             },
-            body: JSON.stringify({ username, password, role }),
+            body: JSON.stringify({ username, password, role, code }),
         });
 
         const data = await response.json();
@@ -124,7 +126,17 @@ document.getElementById('show-login').addEventListener('click', (e) => {
 
 // 退出登录
 document.getElementById('logout-btn').addEventListener('click', () => {
+    // 通知服务器吊销刷新令牌，不等待结果
+    const refreshToken = localStorage.getItem('refresh_token');
+    if (refreshToken) {
+        fetch('/api/auth/logout', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ refresh_token: refreshToken }),
+        }).catch(() => {});
+    }
     localStorage.removeItem('token');
+    localStorage.removeItem('refresh_token');
     localStorage.removeItem('username');
     localStorage.removeItem('role');
     document.getElementById('app').style.display = 'none';
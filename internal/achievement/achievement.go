@@ -0,0 +1,163 @@
+package achievement
+
+import (
+	"calculator/internal/model"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Definition 成就定义
+type Definition struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// triggered 判断一次答题是否可能达成该成就，不相关的答题跳过检查
+	triggered func(event *Event) bool
+	// achieved 根据用户已保存的答题记录判断是否达成，只依赖已保存的状态，重复检查结果相同
+	achieved func(db *gorm.DB, event *Event) (bool, error)
+}
+
+// Event 一次答题事件
+type Event struct {
+	Record *model.HistoryRecord
+	// Streak 答题后用户的连续练习天数（按用户时区）
+	Streak int
+	// Answered 答题后用户的累计答题数（来自缓存，可能略有偏差），0 表示未知
+	Answered int64
+}
+
+// Definitions 所有成就，按展示顺序排列
+var Definitions = []Definition{
+	answered("first_answer", "初出茅庐", "完成第一道题", 1),
+	answered("answered_100", "勤学苦练", "累计答题 100 道", 100),
+	answered("answered_1000", "题海无涯", "累计答题 1000 道", 1000),
+	correctInARow("correct_in_row_10", "渐入佳境", "连续答对 10 道题", 10),
+	correctInARow("correct_in_row_100", "百发百中", "连续答对 100 道题", 100),
+	{
+		Code:        "multiplication_master",
+		Name:        "乘法口诀大师",
+		Description: fmt.Sprintf("答对全部 %d 道乘法口诀题（2~10 × 2~10）", multiplicationFactCount),
+		triggered: func(event *Event) bool {
+			_, _, ok := multiplicationFact(event.Record.Question_content)
+			return ok && event.Record.IsCorrect
+		},
+		achieved: multiplicationMastered,
+	},
+	practiceDays("practice_days_7", "坚持一周", "连续 7 天每天都有练习", 7),
+	practiceDays("practice_days_30", "持之以恒", "连续 30 天每天都有练习", 30),
+}
+
+// Find 按编码查找成就定义
+func Find(code string) (Definition, bool) {
+	for _, def := range Definitions {
+		if def.Code == code {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Evaluate 在一次答题后检查用户尚未获得的成就，返回本次获得的成就
+// 检查结果只取决于已保存的答题记录，且每个成就每人只保存一次，同一事件重复处理不会重复发放
+func Evaluate(db *gorm.DB, event *Event) ([]model.UserAchievement, error) {
+	record := event.Record
+	var earnedCodes []string
+	if err := db.Model(&model.UserAchievement{}).Where("user_id = ?", record.UserID).
+		Pluck("code", &earnedCodes).Error; err != nil {
+		return nil, fmt.Errorf("获取已获得成就失败: %v", err)
+	}
+	earned := make(map[string]bool, len(earnedCodes))
+	for _, code := range earnedCodes {
+		earned[code] = true
+	}
+
+	now := time.Now()
+	var awarded []model.UserAchievement
+	for _, def := range Definitions {
+		if earned[def.Code] || !def.triggered(event) {
+			continue
+		}
+		ok, err := def.achieved(db, event)
+		if err != nil {
+			return nil, fmt.Errorf("检查成就 %s 失败: %v", def.Code, err)
+		}
+		if ok {
+			awarded = append(awarded, model.UserAchievement{UserID: record.UserID, Code: def.Code, EarnedAt: now})
+		}
+	}
+	if len(awarded) == 0 {
+		return nil, nil
+	}
+
+	// 并发处理同一用户的答题时可能同时达成，以先保存的为准
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&awarded).Error; err != nil {
+		return nil, fmt.Errorf("保存成就失败: %v", err)
+	}
+	return awarded, nil
+}
+
+// answered 累计答题数达到 n 道
+// 缓存的答题数达到 n 后才统计答题记录确认，缓存不可用时每次答题都统计
+func answered(code, name, description string, n int64) Definition {
+	return Definition{
+		Code:        code,
+		Name:        name,
+		Description: description,
+		triggered: func(event *Event) bool {
+			return event.Answered == 0 || event.Answered >= n
+		},
+		achieved: func(db *gorm.DB, event *Event) (bool, error) {
+			var count int64
+			err := db.Model(&model.HistoryRecord{}).Scopes(model.CountedAnswers).
+				Where("user_id = ?", event.Record.UserID).Count(&count).Error
+			return count >= n, err
+		},
+	}
+}
+
+// correctInARow 最近 n 次答题全部答对
+func correctInARow(code, name, description string, n int) Definition {
+	return Definition{
+		Code:        code,
+		Name:        name,
+		Description: description,
+		triggered: func(event *Event) bool {
+			return event.Record.IsCorrect
+		},
+		achieved: func(db *gorm.DB, event *Event) (bool, error) {
+			var results []bool
+			if err := db.Model(&model.HistoryRecord{}).Scopes(model.CountedAnswers).Where("user_id = ?", event.Record.UserID).
+				Order("created_at DESC, id DESC").Limit(n).Pluck("is_correct", &results).Error; err != nil {
+				return false, err
+			}
+			if len(results) < n {
+				return false, nil
+			}
+			for _, correct := range results {
+				if !correct {
+					return false, nil
+				}
+			}
+			return true, nil
+		},
+	}
+}
+
+// practiceDays 连续 n 天每天都有答题
+func practiceDays(code, name, description string, n int) Definition {
+	return Definition{
+		Code:        code,
+		Name:        name,
+		Description: description,
+		triggered: func(event *Event) bool {
+			return event.Streak >= n
+		},
+		achieved: func(*gorm.DB, *Event) (bool, error) {
+			return true, nil
+		},
+	}
+}
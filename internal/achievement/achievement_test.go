@@ -0,0 +1,83 @@
+package achievement
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDefinitions_UniqueCodes(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, def := range Definitions {
+		if seen[def.Code] {
+			t.Errorf("成就编码重复: %s", def.Code)
+		}
+		seen[def.Code] = true
+		if def.triggered == nil || def.achieved == nil {
+			t.Errorf("成就 %s 缺少检查规则", def.Code)
+		}
+		if _, ok := Find(def.Code); !ok {
+			t.Errorf("无法查找成就 %s", def.Code)
+		}
+	}
+}
+
+func TestMultiplicationFact(t *testing.T) {
+	tests := []struct {
+		expression string
+		a, b       int
+		ok         bool
+	}{
+		{"3 × 7", 3, 7, true},
+		{"10 × 2", 10, 2, true},
+		{"1 × 5", 0, 0, false},
+		{"11 × 5", 0, 0, false},
+		{"3 + 4 × 5", 0, 0, false},
+		{"12 ÷ 3", 0, 0, false},
+	}
+	for _, tt := range tests {
+		a, b, ok := multiplicationFact(tt.expression)
+		if a != tt.a || b != tt.b || ok != tt.ok {
+			t.Errorf("multiplicationFact(%q) = %d, %d, %v, 期望 %d, %d, %v", tt.expression, a, b, ok, tt.a, tt.b, tt.ok)
+		}
+	}
+}
+
+func TestMasteredFacts(t *testing.T) {
+	var expressions []string
+	for a := multiplicationMin; a <= multiplicationMax; a++ {
+		for b := multiplicationMin; b <= multiplicationMax; b++ {
+			expressions = append(expressions, fmt.Sprintf("%d × %d", a, b))
+		}
+	}
+	if multiplicationFactCount != 81 {
+		t.Fatalf("乘法口诀题应为 81 道, 实际 %d", multiplicationFactCount)
+	}
+
+	// 重复答对和混合运算不计入
+	partial := append([]string{"3 × 7", "3 × 7", "1 + 3 × 7"}, expressions[:10]...)
+	if got := masteredFacts(partial); got != 11 {
+		t.Errorf("应掌握 11 道, 实际 %d", got)
+	}
+	if got := masteredFacts(expressions); got != multiplicationFactCount {
+		t.Errorf("应掌握全部 %d 道, 实际 %d", multiplicationFactCount, got)
+	}
+}
+
+func TestAnswered_Triggered(t *testing.T) {
+	def := answered("answered_100", "勤学苦练", "累计答题 100 道", 100)
+	tests := []struct {
+		answered int64
+		want     bool
+	}{
+		{0, true}, // 缓存不可用时直接统计
+		{1, false},
+		{99, false},
+		{100, true},
+		{150, true},
+	}
+	for _, tt := range tests {
+		if got := def.triggered(&Event{Answered: tt.answered}); got != tt.want {
+			t.Errorf("累计答题 %d 道时 triggered = %v, 期望 %v", tt.answered, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package achievement
+
+import (
+	"calculator/internal/model"
+	"regexp"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// 乘法口诀题的因数范围，与题目生成器的乘法题一致
+	multiplicationMin = 2
+	multiplicationMax = 10
+	// multiplicationFactCount 乘法口诀题总数
+	multiplicationFactCount = (multiplicationMax - multiplicationMin + 1) * (multiplicationMax - multiplicationMin + 1)
+)
+
+// multiplicationPattern 单独的乘法题，如 "3 × 7"
+var multiplicationPattern = regexp.MustCompile(`^(\d+) × (\d+)$`)
+
+// multiplicationFact 解析乘法口诀题，返回两个因数
+func multiplicationFact(expression string) (int, int, bool) {
+	match := multiplicationPattern.FindStringSubmatch(expression)
+	if match == nil {
+		return 0, 0, false
+	}
+	a, _ := strconv.Atoi(match[1])
+	b, _ := strconv.Atoi(match[2])
+	if a < multiplicationMin || a > multiplicationMax || b < multiplicationMin || b > multiplicationMax {
+		return 0, 0, false
+	}
+	return a, b, true
+}
+
+// masteredFacts 统计答对过的题目中不同乘法口诀题的数量，3 × 7 与 7 × 3 算作两道
+func masteredFacts(expressions []string) int {
+	facts := make(map[[2]int]bool)
+	for _, expression := range expressions {
+		if a, b, ok := multiplicationFact(expression); ok {
+			facts[[2]int{a, b}] = true
+		}
+	}
+	return len(facts)
+}
+
+// multiplicationMastered 全部乘法口诀题都至少答对过一次
+func multiplicationMastered(db *gorm.DB, event *Event) (bool, error) {
+	var expressions []string
+	if err := db.Model(&model.HistoryRecord{}).Scopes(model.CountedAnswers).
+		Where("user_id = ? AND is_correct = ? AND question_content LIKE ?", event.Record.UserID, true, "% × %").
+		Distinct().Pluck("question_content", &expressions).Error; err != nil {
+		return false, err
+	}
+	return masteredFacts(expressions) >= multiplicationFactCount, nil
+}
@@ -0,0 +1,35 @@
+package coin
+
+// 装扮类别
+const (
+	CategoryAvatar = "avatar" // 头像
+	CategoryTheme  = "theme"  // 主题
+)
+
+// Item 可以用金币兑换的装扮
+type Item struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Price    int64  `json:"price"`
+}
+
+// Catalog 所有可兑换的装扮，按展示顺序排列
+var Catalog = []Item{
+	{Code: "avatar_cat", Name: "小猫头像", Category: CategoryAvatar, Price: 50},
+	{Code: "avatar_rocket", Name: "火箭头像", Category: CategoryAvatar, Price: 80},
+	{Code: "avatar_dragon", Name: "小龙头像", Category: CategoryAvatar, Price: 200},
+	{Code: "theme_ocean", Name: "海洋主题", Category: CategoryTheme, Price: 100},
+	{Code: "theme_forest", Name: "森林主题", Category: CategoryTheme, Price: 100},
+	{Code: "theme_space", Name: "星空主题", Category: CategoryTheme, Price: 300},
+}
+
+// FindItem 按编码查找装扮
+func FindItem(code string) (Item, bool) {
+	for _, item := range Catalog {
+		if item.Code == code {
+			return item, true
+		}
+	}
+	return Item{}, false
+}
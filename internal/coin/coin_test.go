@@ -0,0 +1,73 @@
+package coin
+
+import (
+	"calculator/internal/model"
+	"testing"
+)
+
+func TestCheckLedger(t *testing.T) {
+	entries := []model.CoinTransaction{
+		{ID: 1, Amount: 30, BalanceAfter: 30},
+		{ID: 2, Amount: 2, BalanceAfter: 32},
+		{ID: 3, Amount: -20, BalanceAfter: 12},
+	}
+
+	if audit := checkLedger(entries, 12); !audit.Consistent || audit.LedgerSum != 12 {
+		t.Errorf("账目应一致: %+v", audit)
+	}
+	if audit := checkLedger(nil, 0); !audit.Consistent {
+		t.Errorf("没有流水且余额为 0 时应一致: %+v", audit)
+	}
+
+	// 余额表被直接修改
+	if audit := checkLedger(entries, 100); audit.Consistent || len(audit.Problems) != 1 {
+		t.Errorf("余额与流水不一致时应报告问题: %+v", audit)
+	}
+
+	// 流水中记录的余额被篡改
+	tampered := append([]model.CoinTransaction(nil), entries...)
+	tampered[1].BalanceAfter = 50
+	if audit := checkLedger(tampered, 12); audit.Consistent || len(audit.Problems) != 1 {
+		t.Errorf("流水余额不连续时应报告问题: %+v", audit)
+	}
+
+	// 消费超过余额
+	overdrawn := []model.CoinTransaction{{ID: 1, Amount: -5, BalanceAfter: -5}}
+	if audit := checkLedger(overdrawn, -5); audit.Consistent {
+		t.Errorf("余额为负时应报告问题: %+v", audit)
+	}
+}
+
+func TestRewards(t *testing.T) {
+	if got := AnswerReward("hard"); got != 3 {
+		t.Errorf("困难题应得 3 金币, 实际 %d", got)
+	}
+	if got := AnswerReward("unknown"); got != 1 {
+		t.Errorf("未知难度应得 1 金币, 实际 %d", got)
+	}
+	if got := StreakReward(7); got != 30 {
+		t.Errorf("连续 7 天应得 30 金币, 实际 %d", got)
+	}
+	if got := StreakReward(8); got != 0 {
+		t.Errorf("非里程碑不发放金币, 实际 %d", got)
+	}
+	if HomeworkReward(true) >= HomeworkReward(false) {
+		t.Errorf("迟交作业的奖励应少于按时完成")
+	}
+}
+
+func TestCatalog_UniqueCodes(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, item := range Catalog {
+		if seen[item.Code] {
+			t.Errorf("装扮编码重复: %s", item.Code)
+		}
+		seen[item.Code] = true
+		if item.Price <= 0 {
+			t.Errorf("装扮 %s 价格必须大于 0", item.Code)
+		}
+		if item.Category != CategoryAvatar && item.Category != CategoryTheme {
+			t.Errorf("装扮 %s 类别无效: %s", item.Code, item.Category)
+		}
+	}
+}
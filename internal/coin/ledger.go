@@ -0,0 +1,143 @@
+package coin
+
+import (
+	"calculator/internal/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrDuplicate 同一来源的金币已经记录过
+	ErrDuplicate = errors.New("该来源的金币已记录")
+	// ErrInsufficientBalance 余额不足
+	ErrInsufficientBalance = errors.New("金币余额不足")
+	// ErrAlreadyOwned 已经兑换过该装扮
+	ErrAlreadyOwned = errors.New("已拥有该装扮")
+)
+
+// Post 在事务 tx 中追加一条金币流水并更新余额
+// 先锁定用户的余额行，同一用户的流水依次写入，变动后余额不能为负
+func Post(tx *gorm.DB, userID uint, amount int64, reason, reference string) (*model.CoinTransaction, error) {
+	wallet := model.CoinWallet{UserID: userID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&wallet).Error; err != nil {
+		return nil, fmt.Errorf("创建金币余额失败: %v", err)
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, "user_id = ?", userID).Error; err != nil {
+		return nil, fmt.Errorf("获取金币余额失败: %v", err)
+	}
+
+	var count int64
+	if err := tx.Model(&model.CoinTransaction{}).
+		Where("user_id = ? AND reason = ? AND reference = ?", userID, reason, reference).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("获取金币流水失败: %v", err)
+	}
+	if count > 0 {
+		return nil, ErrDuplicate
+	}
+
+	balance := wallet.Balance + amount
+	if balance < 0 {
+		return nil, ErrInsufficientBalance
+	}
+
+	entry := model.CoinTransaction{
+		UserID:       userID,
+		Amount:       amount,
+		BalanceAfter: balance,
+		Reason:       reason,
+		Reference:    reference,
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, fmt.Errorf("保存金币流水失败: %v", err)
+	}
+	if err := tx.Model(&wallet).Update("balance", balance).Error; err != nil {
+		return nil, fmt.Errorf("更新金币余额失败: %v", err)
+	}
+	return &entry, nil
+}
+
+// Earn 发放金币，同一来源已经发放过时不重复发放
+func Earn(db *gorm.DB, userID uint, amount int64, reason, reference string) error {
+	if amount <= 0 {
+		return nil
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := Post(tx, userID, amount, reason, reference)
+		return err
+	})
+	if errors.Is(err, ErrDuplicate) {
+		return nil
+	}
+	return err
+}
+
+// Purchase 用金币兑换装扮
+func Purchase(db *gorm.DB, userID uint, item Item) (*model.UserItem, error) {
+	owned := model.UserItem{
+		UserID:      userID,
+		ItemCode:    item.Code,
+		Category:    item.Category,
+		PurchasedAt: time.Now(),
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := Post(tx, userID, -item.Price, model.CoinReasonPurchase, "item:"+item.Code); err != nil {
+			if errors.Is(err, ErrDuplicate) {
+				return ErrAlreadyOwned
+			}
+			return err
+		}
+		return tx.Create(&owned).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &owned, nil
+}
+
+// Audit 金币账目核对结果
+type Audit struct {
+	Balance    int64    `json:"balance"`    // 余额表中的余额
+	LedgerSum  int64    `json:"ledger_sum"` // 流水金额之和
+	Consistent bool     `json:"consistent"` // 余额与流水是否一致
+	Problems   []string `json:"problems,omitempty"`
+}
+
+// Verify 核对用户的金币余额与流水
+func Verify(db *gorm.DB, userID uint) (*Audit, error) {
+	var wallet model.CoinWallet
+	if err := db.Where("user_id = ?", userID).Limit(1).Find(&wallet).Error; err != nil {
+		return nil, fmt.Errorf("获取金币余额失败: %v", err)
+	}
+
+	var entries []model.CoinTransaction
+	if err := db.Where("user_id = ?", userID).Order("id").Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("获取金币流水失败: %v", err)
+	}
+
+	return checkLedger(entries, wallet.Balance), nil
+}
+
+// checkLedger 按顺序核对流水：每条流水记录的余额等于之前所有流水金额之和，余额从不为负，且最终等于余额表中的余额
+func checkLedger(entries []model.CoinTransaction, balance int64) *Audit {
+	audit := &Audit{Balance: balance}
+	for _, entry := range entries {
+		audit.LedgerSum += entry.Amount
+		if entry.BalanceAfter != audit.LedgerSum {
+			audit.Problems = append(audit.Problems,
+				fmt.Sprintf("流水 %d 记录的余额为 %d，按流水计算应为 %d", entry.ID, entry.BalanceAfter, audit.LedgerSum))
+		}
+		if audit.LedgerSum < 0 {
+			audit.Problems = append(audit.Problems, fmt.Sprintf("流水 %d 之后余额为负", entry.ID))
+		}
+	}
+	if audit.LedgerSum != balance {
+		audit.Problems = append(audit.Problems, fmt.Sprintf("余额 %d 与流水合计 %d 不一致", balance, audit.LedgerSum))
+	}
+	audit.Consistent = len(audit.Problems) == 0
+	return audit
+}
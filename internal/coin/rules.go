@@ -0,0 +1,35 @@
+package coin
+
+// answerRewards 答对一题获得的金币，按难度
+var answerRewards = map[string]int64{"easy": 1, "medium": 2, "hard": 3}
+
+// streakRewards 连续练习天数达到里程碑时获得的金币
+var streakRewards = map[int]int64{3: 10, 7: 30, 14: 50, 30: 100, 60: 200, 100: 300}
+
+const (
+	// homeworkReward 按时完成作业获得的金币
+	homeworkReward = 20
+	// lateHomeworkReward 迟交作业获得的金币
+	lateHomeworkReward = 5
+)
+
+// AnswerReward 答对一道题获得的金币
+func AnswerReward(difficulty string) int64 {
+	if reward, ok := answerRewards[difficulty]; ok {
+		return reward
+	}
+	return 1
+}
+
+// StreakReward 连续练习达到 days 天时获得的金币，不是里程碑时为 0
+func StreakReward(days int) int64 {
+	return streakRewards[days]
+}
+
+// HomeworkReward 完成作业获得的金币
+func HomeworkReward(late bool) int64 {
+	if late {
+		return lateHomeworkReward
+	}
+	return homeworkReward
+}
@@ -14,6 +14,32 @@ import (
 
 var DB *gorm.DB
 
+// migrateModels 需要自动迁移的模型
+var migrateModels = []interface{}{
+	&model.User{},
+	&model.Session{},
+	&model.HistoryRecord{},
+	&model.PracticeSession{},
+	&model.SprintResult{},
+	&model.ExamPaper{},
+	&model.ExamAttempt{},
+	&model.School{},
+	&model.Class{},
+	&model.Enrollment{},
+	&model.Homework{},
+	&model.ParentLink{},
+	&model.InviteCode{},
+	&model.Season{},
+	&model.SeasonStanding{},
+	&model.RankSnapshot{},
+	&model.UserAchievement{},
+	&model.DailyGoal{},
+	&model.GoalRecord{},
+	&model.CoinWallet{},
+	&model.CoinTransaction{},
+	&model.UserItem{},
+}
+
 // InitDB 初始化数据库连接
 func InitDB() error {
 	// 加载环境变量
@@ -31,7 +57,7 @@ func InitDB() error {
 	}
 
 	// 自动迁移数据库表
-	if migrateErr := DB.AutoMigrate(&model.User{}, &model.Session{}, &model.HistoryRecord{}); migrateErr != nil {
+	if migrateErr := DB.AutoMigrate(migrateModels...); migrateErr != nil {
 		return fmt.Errorf("数据库迁移失败: %v", migrateErr)
 	}
 
@@ -61,7 +87,7 @@ func New(connectionString string) (*Database, error) {
 	}
 
 	// Auto migrate models
-	err = db.AutoMigrate(&model.User{}, &model.Session{}, &model.HistoryRecord{})
+	err = db.AutoMigrate(migrateModels...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to auto migrate models: %v", err)
 	}
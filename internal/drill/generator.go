@@ -16,6 +16,73 @@ const (
 	Hard
 )
 
+// String 返回难度对应的字符串（easy/medium/hard）
+func (d Difficulty) String() string {
+	switch d {
+	case Medium:
+		return "medium"
+	case Hard:
+		return "hard"
+	default:
+		return "easy"
+	}
+}
+
+// ParseDifficulty 将难度字符串解析为 Difficulty，无法识别时返回 false
+func ParseDifficulty(s string) (Difficulty, bool) {
+	switch s {
+	case "easy":
+		return Easy, true
+	case "medium":
+		return Medium, true
+	case "hard":
+		return Hard, true
+	default:
+		return Easy, false
+	}
+}
+
+// 题型，作业可以指定只出其中几种
+const (
+	TemplateAdd         = "add"          // 加法
+	TemplateSubtract    = "subtract"     // 减法
+	TemplateMultiply    = "multiply"     // 乘法
+	TemplateDivide      = "divide"       // 除法
+	TemplateMixed       = "mixed"        // 先乘后加减的混合运算
+	TemplateMultiStep   = "multi_step"   // 多步混合运算
+	TemplateParentheses = "parentheses"  // 带括号运算
+	TemplateLargeNumber = "large_number" // 大数运算
+)
+
+// Templates 各难度可选的题型，顺序与各难度生成函数中的运算类型编号一致
+var Templates = map[Difficulty][]string{
+	Easy:   {TemplateAdd, TemplateSubtract, TemplateMultiply},
+	Medium: {TemplateAdd, TemplateSubtract, TemplateMultiply, TemplateDivide, TemplateMixed},
+	Hard:   {TemplateMultiStep, TemplateParentheses, TemplateLargeNumber},
+}
+
+// ValidTemplates 判断题型组合是否都属于该难度且没有重复
+func ValidTemplates(difficulty Difficulty, templates []string) bool {
+	seen := make(map[string]bool, len(templates))
+	for _, template := range templates {
+		if seen[template] || templateIndex(difficulty, template) < 0 {
+			return false
+		}
+		seen[template] = true
+	}
+	return true
+}
+
+// templateIndex 返回题型在该难度中的运算类型编号，不属于该难度时返回 -1
+func templateIndex(difficulty Difficulty, template string) int {
+	for i, t := range Templates[difficulty] {
+		if t == template {
+			return i
+		}
+	}
+	return -1
+}
+
 // Question 表示一道口算题
 type Question struct {
 	Expression string // 表达式如 "3 + 5"
@@ -37,23 +104,49 @@ func NewGenerator() *Generator {
 
 // Generate 根据难度生成题目
 func (g *Generator) Generate(difficulty Difficulty) Question {
+	return g.GenerateFrom(difficulty, nil)
+}
+
+// GenerateFrom 从指定的题型中随机选择一种生成题目，templates 为空或包含无效题型时使用该难度的全部题型
+func (g *Generator) GenerateFrom(difficulty Difficulty, templates []string) Question {
+	if _, ok := Templates[difficulty]; !ok {
+		difficulty = Easy
+	}
+	if len(templates) == 0 || !ValidTemplates(difficulty, templates) {
+		templates = Templates[difficulty]
+	}
+	opType := templateIndex(difficulty, templates[g.rng.Intn(len(templates))])
+
 	switch difficulty {
-	case Easy:
-		return g.generateEasy()
 	case Medium:
-		return g.generateMedium()
+		return g.generateMedium(opType)
 	case Hard:
-		return g.generateHard()
+		return g.generateHard(opType)
 	default:
-		return g.generateEasy()
+		return g.generateEasy(opType)
 	}
 }
 
-// generateEasy 生成简单题目(10以内加减法，2-5的乘法)
-func (g *Generator) generateEasy() Question {
-	// 随机选择运算类型：0-加法，1-减法，2-乘法
-	opType := g.rng.Intn(3)
+// GenerateSet 生成一组指定难度的题目，尽量避免重复的表达式
+func (g *Generator) GenerateSet(difficulty Difficulty, count int) []Question {
+	questions := make([]Question, 0, count)
+	seen := make(map[string]bool, count)
+	// 题库较小时允许重复，避免无限循环
+	maxAttempts := count * 10
+	for attempts := 0; len(questions) < count; attempts++ {
+		question := g.Generate(difficulty)
+		if seen[question.Expression] && attempts < maxAttempts {
+			continue
+		}
+		seen[question.Expression] = true
+		questions = append(questions, question)
+	}
+	return questions
+}
 
+// generateEasy 生成简单题目(10以内加减法，2-5的乘法)
+// 运算类型：0-加法，1-减法，2-乘法
+func (g *Generator) generateEasy(opType int) Question {
 	switch opType {
 	case 0: // 加法
 		a := g.rng.Intn(10) + 1 // 1-10
@@ -83,15 +176,13 @@ func (g *Generator) generateEasy() Question {
 			Difficulty: Easy,
 		}
 	default:
-		return g.generateEasy()
+		return g.generateEasy(g.rng.Intn(3))
 	}
 }
 
 // generateMedium 生成中等题目(两位数加减法，乘法表扩展，简单除法)
-func (g *Generator) generateMedium() Question {
-	// 随机选择运算类型：0-加法，1-减法，2-乘法，3-除法，4-混合运算
-	opType := g.rng.Intn(5)
-
+// 运算类型：0-加法，1-减法，2-乘法，3-除法，4-混合运算
+func (g *Generator) generateMedium(opType int) Question {
 	switch opType {
 	case 0: // 加法
 		a := g.rng.Intn(50) + 1 // 1-50
@@ -143,15 +234,13 @@ func (g *Generator) generateMedium() Question {
 			Difficulty: Medium,
 		}
 	default:
-		return g.generateEasy()
+		return g.generateEasy(g.rng.Intn(3))
 	}
 }
 
 // generateHard 生成困难题目(多步运算、大数运算、带括号运算)
-func (g *Generator) generateHard() Question {
-	// 随机选择运算类型：0-多步混合运算，1-带括号运算，2-大数运算
-	opType := g.rng.Intn(3)
-
+// 运算类型：0-多步混合运算，1-带括号运算，2-大数运算
+func (g *Generator) generateHard(opType int) Question {
 	switch opType {
 	case 0: // 多步混合运算
 		a := g.rng.Intn(20) + 1 // 1-20
@@ -249,7 +338,7 @@ func (g *Generator) generateHard() Question {
 		}
 
 	default:
-		return g.generateMedium()
+		return g.generateMedium(g.rng.Intn(5))
 	}
 }
 
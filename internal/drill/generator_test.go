@@ -1,6 +1,7 @@
 package drill
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -37,3 +38,60 @@ func TestGenerator_Generate(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerator_GenerateSet(t *testing.T) {
+	g := NewGenerator()
+
+	questions := g.GenerateSet(Medium, 20)
+	if len(questions) != 20 {
+		t.Fatalf("题目数量不正确, 期望 %d, 实际 %d", 20, len(questions))
+	}
+
+	seen := make(map[string]bool)
+	for _, q := range questions {
+		if q.Difficulty != Medium {
+			t.Errorf("难度不匹配, 期望 %d, 实际 %d", Medium, q.Difficulty)
+		}
+		if seen[q.Expression] {
+			t.Errorf("题目重复: %s", q.Expression)
+		}
+		seen[q.Expression] = true
+	}
+}
+
+func TestGenerator_GenerateFrom(t *testing.T) {
+	g := NewGenerator()
+
+	for i := 0; i < 50; i++ {
+		q := g.GenerateFrom(Medium, []string{TemplateDivide})
+		if !strings.Contains(q.Expression, "÷") || strings.ContainsAny(q.Expression, "+-×") {
+			t.Fatalf("只应生成除法题, 实际 %s", q.Expression)
+		}
+	}
+	for i := 0; i < 50; i++ {
+		q := g.GenerateFrom(Easy, []string{TemplateAdd, TemplateSubtract})
+		if strings.Contains(q.Expression, "×") {
+			t.Fatalf("不应生成乘法题, 实际 %s", q.Expression)
+		}
+	}
+}
+
+func TestValidTemplates(t *testing.T) {
+	tests := []struct {
+		difficulty Difficulty
+		templates  []string
+		want       bool
+	}{
+		{Easy, nil, true},
+		{Easy, []string{TemplateAdd, TemplateMultiply}, true},
+		{Easy, []string{TemplateDivide}, false},
+		{Medium, []string{TemplateMixed, TemplateMixed}, false},
+		{Hard, []string{TemplateParentheses}, true},
+		{Hard, []string{"unknown"}, false},
+	}
+	for _, tt := range tests {
+		if got := ValidTemplates(tt.difficulty, tt.templates); got != tt.want {
+			t.Errorf("ValidTemplates(%v, %v) = %v, 期望 %v", tt.difficulty, tt.templates, got, tt.want)
+		}
+	}
+}
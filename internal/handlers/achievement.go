@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"calculator/internal/achievement"
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// evaluateAchievements 检查一次答题后达成的成就，streak 为答题后的连续练习天数，answered 为累计答题数
+func evaluateAchievements(record *model.HistoryRecord, streak int, answered int64) {
+	event := achievement.Event{Record: record, Streak: streak, Answered: answered}
+	if _, err := achievement.Evaluate(database.DB, &event); err != nil {
+		fmt.Printf("检查成就失败: %v\n", err)
+	}
+}
+
+// achievementItem 成就及用户的获得情况
+type achievementItem struct {
+	achievement.Definition
+	Earned   bool       `json:"earned"`
+	EarnedAt *time.Time `json:"earned_at,omitempty"`
+}
+
+// GetAchievements 获取所有成就及当前用户的获得情况
+func GetAchievements(c *gin.Context) {
+	respondAchievements(c, c.GetUint("user_id"))
+}
+
+// GetChildAchievements 家长查看孩子获得的成就
+func GetChildAchievements(c *gin.Context) {
+	studentID, ok := loadChild(c)
+	if !ok {
+		return
+	}
+	respondAchievements(c, studentID)
+}
+
+// respondAchievements 返回所有成就及指定用户的获得情况
+func respondAchievements(c *gin.Context, userID uint) {
+	var earned []model.UserAchievement
+	if err := database.DB.Where("user_id = ?", userID).Find(&earned).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取成就失败"})
+		return
+	}
+	earnedAt := make(map[string]time.Time, len(earned))
+	for _, item := range earned {
+		earnedAt[item.Code] = item.EarnedAt
+	}
+
+	items := make([]achievementItem, 0, len(achievement.Definitions))
+	for _, def := range achievement.Definitions {
+		item := achievementItem{Definition: def}
+		if at, ok := earnedAt[def.Code]; ok {
+			item.Earned = true
+			item.EarnedAt = &at
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"achievements": items,
+		"earned":       len(earned),
+		"total":        len(items),
+	})
+}
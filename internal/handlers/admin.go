@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// 邀请码长度
+	inviteCodeLength = 10
+	// 邀请码默认有效期
+	defaultInviteTTL = 7 * 24 * time.Hour
+)
+
+// CreateInvite 管理员生成教师注册邀请码
+func CreateInvite(c *gin.Context) {
+	var req struct {
+		Role      string `json:"role"`
+		ExpiresIn int    `json:"expires_in"` // 有效期（小时），默认 7 天
+		SchoolID  *uint  `json:"school_id"`  // 教师所在的学校，可不填
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = model.RoleTeacher
+	}
+	// 目前只有教师需要邀请码
+	if req.Role != model.RoleTeacher {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能为教师生成邀请码"})
+		return
+	}
+
+	if req.SchoolID != nil {
+		if err := database.DB.First(&model.School{}, *req.SchoolID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "学校不存在"})
+			return
+		}
+	}
+
+	ttl := defaultInviteTTL
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Hour
+	}
+
+	code, err := generateCode(inviteCodeLength)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成邀请码失败"})
+		return
+	}
+
+	invite := model.InviteCode{
+		Code:      code,
+		Role:      req.Role,
+		SchoolID:  req.SchoolID,
+		CreatedBy: c.GetUint("user_id"),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	if err := database.DB.Create(&invite).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存邀请码失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invite)
+}
+
+// CreateSchool 管理员创建学校，教师创建班级时可选择所属学校
+func CreateSchool(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供学校名称"})
+		return
+	}
+
+	school := model.School{Name: req.Name}
+	if err := database.DB.Where(school).FirstOrCreate(&school).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建学校失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, school)
+}
+
+// SetTeacherSchool 管理员设置教师所在的学校，school_id 为 null 表示不属于任何学校
+// 已创建的班级不受影响
+func SetTeacherSchool(c *gin.Context) {
+	var req struct {
+		SchoolID *uint `json:"school_id"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	teacherID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if req.SchoolID != nil {
+		if err := database.DB.First(&model.School{}, *req.SchoolID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "学校不存在"})
+			return
+		}
+	}
+
+	result := database.DB.Model(&model.User{}).
+		Where("id = ? AND role = ?", teacherID, model.RoleTeacher).
+		Update("school_id", req.SchoolID)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "设置学校失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		// 学校未变化时也没有受影响的行，再确认教师是否存在
+		var count int64
+		if err := database.DB.Model(&model.User{}).Where("id = ? AND role = ?", teacherID, model.RoleTeacher).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "设置学校失败"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "教师不存在"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已设置教师所在学校"})
+}
+
+// GetSchools 获取学校列表
+func GetSchools(c *gin.Context) {
+	var schools []model.School
+	if err := database.DB.Order("name").Find(&schools).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取学校列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schools)
+}
+
+// GetInvites 管理员查看邀请码及使用情况
+func GetInvites(c *gin.Context) {
+	var invites []model.InviteCode
+	if err := database.DB.Order("created_at DESC").Find(&invites).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取邀请码失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, invites)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"calculator/internal/model"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// answerEventQueueSize 等待后台处理的答题数上限
+const answerEventQueueSize = 1024
+
+// answerEventQueue 在后台处理答题后的每日目标进度、成就检查和连续练习金币，答题接口不等待处理结果
+// 队列已满时跳过本次处理：目标进度、成就和连续练习金币都按已保存的状态重新检查，用户当天再次答题时会补上；
+// 答对的金币在保存答题记录时同步发放，不经过队列
+type answerEventQueue struct {
+	once   sync.Once
+	events chan model.HistoryRecord
+}
+
+var defaultAnswerEvents = &answerEventQueue{
+	events: make(chan model.HistoryRecord, answerEventQueueSize),
+}
+
+// publish 提交一次答题，首次提交时启动后台处理
+func (q *answerEventQueue) publish(record *model.HistoryRecord) {
+	q.once.Do(func() {
+		go q.run()
+	})
+
+	select {
+	case q.events <- *record:
+	default:
+		fmt.Printf("答题事件队列已满，跳过答题记录 %d\n", record.ID)
+	}
+}
+
+// run 依次处理答题事件
+func (q *answerEventQueue) run() {
+	ctx := context.Background()
+	for record := range q.events {
+		streak := 0
+		if s, err := defaultDrillHandler.redis.GetStreak(ctx, record.UserID, record.CreatedAt); err != nil {
+			fmt.Printf("获取连续练习天数失败: %v\n", err)
+		} else {
+			streak = s.Current
+		}
+
+		answered, err := defaultDrillHandler.redis.IncrAnswerCount(ctx, record.UserID)
+		if err != nil {
+			fmt.Printf("获取累计答题数失败: %v\n", err)
+		}
+
+		updateGoalProgress(ctx, &record)
+		evaluateAchievements(&record, streak, answered)
+		awardStreakCoins(ctx, &record, streak)
+	}
+}
@@ -3,21 +3,47 @@ package handlers
 import (
 	"calculator/internal/database"
 	"calculator/internal/model"
+	"crypto/rand"
+	"errors"
+	"fmt"
 	"log"
+	"math/big"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// 邀请码、班级码的字符集，去掉了容易混淆的 0/O、1/I
+const codeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// errInvalidCode 邀请码或班级码无效
+var errInvalidCode = errors.New("invalid code")
+
+// generateCode 生成指定长度的随机码
+func generateCode(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(codeAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("生成随机码失败: %v", err)
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
 // Register 用户注册
+// 教师需要管理员发放的邀请码，学生需要老师提供的班级码（或由老师导入名单），家长可直接注册
 func Register(c *gin.Context) {
 	var input struct {
 		Username string `json:"username" binding:"required"`
 		Password string `json:"password" binding:"required"`
 		Role     string `json:"role" binding:"required"`
+		Code     string `json:"code"` // 教师填写邀请码，学生填写班级码
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -28,6 +54,28 @@ func Register(c *gin.Context) {
 
 	log.Printf("收到注册请求: username=%s, role=%s", input.Username, input.Role)
 
+	if !model.ValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的角色"})
+		return
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(input.Code))
+	switch input.Role {
+	case model.RoleAdmin:
+		c.JSON(http.StatusForbidden, gin.H{"error": "管理员账号只能通过命令行创建"})
+		return
+	case model.RoleTeacher:
+		if code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "教师注册需要邀请码"})
+			return
+		}
+	case model.RoleStudent:
+		if code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "学生注册需要老师提供的班级码"})
+			return
+		}
+	}
+
 	// 检查用户名是否已存在
 	var existingUser model.User
 	if err := database.DB.Where("username = ?", input.Username).First(&existingUser).Error; err == nil {
@@ -51,7 +99,52 @@ func Register(c *gin.Context) {
 		Role:     input.Role,
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		switch user.Role {
+		case model.RoleTeacher:
+			// 邀请码只能使用一次
+			result := tx.Model(&model.InviteCode{}).
+				Where("code = ? AND role = ? AND used_by IS NULL AND expires_at > ?", code, user.Role, time.Now()).
+				Updates(map[string]interface{}{"used_by": user.ID, "used_at": time.Now()})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errInvalidCode
+			}
+			// 邀请码指定了学校时，教师属于该学校
+			var invite model.InviteCode
+			if err := tx.Where("code = ?", code).First(&invite).Error; err != nil {
+				return err
+			}
+			if invite.SchoolID != nil {
+				if err := tx.Model(&user).Update("school_id", *invite.SchoolID).Error; err != nil {
+					return err
+				}
+			}
+		case model.RoleStudent:
+			var class model.Class
+			if err := tx.Where("join_code = ?", code).First(&class).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errInvalidCode
+				}
+				return err
+			}
+			if err := tx.Create(&model.Enrollment{ClassID: class.ID, StudentID: user.ID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errInvalidCode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "邀请码或班级码无效"})
+		return
+	}
+	if err != nil {
 		log.Printf("创建用户失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建用户失败"})
 		return
@@ -91,32 +184,36 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// 生成 JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":  user.ID,
-		"username": user.Username,
-		"role":     user.Role,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
-	})
+	log.Printf("用户登录成功: username=%s", user.Username)
+	// 签发短期访问令牌和刷新令牌
+	startSession(c, &user)
+}
 
-	tokenString, err := token.SignedString([]byte("your-secret-key"))
-	if err != nil {
-		log.Printf("生成token失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
+// Logout 用户登出，吊销本次登录的所有刷新令牌，已签发的访问令牌随即失效
+func Logout(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供刷新令牌"})
 		return
 	}
 
-	log.Printf("用户登录成功: username=%s", user.Username)
-	c.JSON(http.StatusOK, gin.H{
-		"token":    tokenString,
-		"username": user.Username,
-		"role":     user.Role,
-	})
-}
+	var session model.Session
+	if err := database.DB.Where("token = ?", hashRefreshToken(input.RefreshToken)).First(&session).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "登出失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "登出成功"})
+		return
+	}
+
+	if err := revokeSessionFamily(c.Request.Context(), session.FamilyID); err != nil {
+		log.Printf("吊销会话失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登出失败"})
+		return
+	}
 
-// Logout 用户登出
-func Logout(c *gin.Context) {
-	// 由于使用 JWT，服务器端不需要维护会话状态
-	// 客户端只需要删除本地存储的 token 即可
 	c.JSON(http.StatusOK, gin.H{"message": "登出成功"})
 }
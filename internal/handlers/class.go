@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"calculator/internal/roster"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// classJoinCodeLength 班级码长度
+const classJoinCodeLength = 6
+
+// rosterStudent 班级名单中的学生
+type rosterStudent struct {
+	ID        uint   `json:"id"`
+	Username  string `json:"username"`
+	Name      string `json:"name,omitempty"`
+	StudentNo string `json:"student_no,omitempty"`
+}
+
+// CreateClass 教师创建班级
+func CreateClass(c *gin.Context) {
+	var req struct {
+		Name     string `json:"name" binding:"required"`
+		SchoolID *uint  `json:"school_id"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供班级名称"})
+		return
+	}
+
+	// 班级只能归属于教师所在的学校，未指定时默认为教师所在的学校
+	var teacher model.User
+	if err := database.DB.First(&teacher, c.GetUint("user_id")).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取教师信息失败"})
+		return
+	}
+	if req.SchoolID == nil {
+		req.SchoolID = teacher.SchoolID
+	} else if teacher.SchoolID == nil || *teacher.SchoolID != *req.SchoolID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只能在自己所在的学校下创建班级"})
+		return
+	}
+
+	joinCode, err := generateCode(classJoinCodeLength)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成班级码失败"})
+		return
+	}
+
+	class := model.Class{
+		TeacherID: c.GetUint("user_id"),
+		SchoolID:  req.SchoolID,
+		Name:      req.Name,
+		JoinCode:  &joinCode,
+	}
+
+	if err := database.DB.Create(&class).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建班级失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, class)
+}
+
+// GetClasses 获取教师的班级列表及人数
+func GetClasses(c *gin.Context) {
+	var classes []struct {
+		model.Class
+		StudentCount int64 `json:"student_count"`
+	}
+
+	if err := database.DB.Model(&model.Class{}).
+		Select("classes.*, COUNT(enrollments.id) AS student_count").
+		Joins("LEFT JOIN enrollments ON enrollments.class_id = classes.id").
+		Where("classes.teacher_id = ?", c.GetUint("user_id")).
+		Group("classes.id").
+		Order("classes.created_at DESC").
+		Scan(&classes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取班级列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, classes)
+}
+
+// GetClass 获取班级详情和学生名单
+func GetClass(c *gin.Context) {
+	class, ok := loadTeacherClass(c)
+	if !ok {
+		return
+	}
+
+	var students []rosterStudent
+	if err := database.DB.Model(&model.User{}).
+		Select("users.id, users.username, users.name, users.student_no").
+		Joins("JOIN enrollments ON enrollments.student_id = users.id").
+		Where("enrollments.class_id = ?", class.ID).
+		Order("users.username").
+		Scan(&students).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取学生名单失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"class":    class,
+		"students": students,
+	})
+}
+
+// ResetClassJoinCode 重新生成班级码，旧的班级码立即失效
+func ResetClassJoinCode(c *gin.Context) {
+	class, ok := loadTeacherClass(c)
+	if !ok {
+		return
+	}
+
+	joinCode, err := generateCode(classJoinCodeLength)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成班级码失败"})
+		return
+	}
+
+	if err := database.DB.Model(class).Update("join_code", joinCode).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存班级码失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"join_code": joinCode})
+}
+
+// AddClassStudent 将学生加入班级
+func AddClassStudent(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供学生用户名"})
+		return
+	}
+
+	class, ok := loadTeacherClass(c)
+	if !ok {
+		return
+	}
+
+	var student model.User
+	if err := database.DB.Where("username = ? AND role = ?", req.Username, model.RoleStudent).First(&student).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "学生不存在"})
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&model.Enrollment{}).
+		Where("class_id = ? AND student_id = ?", class.ID, student.ID).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加学生失败"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "学生已在班级中"})
+		return
+	}
+
+	enrollment := model.Enrollment{ClassID: class.ID, StudentID: student.ID}
+	if err := database.DB.Create(&enrollment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加学生失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rosterStudent{
+		ID:        student.ID,
+		Username:  student.Username,
+		Name:      student.Name,
+		StudentNo: student.StudentNo,
+	})
+}
+
+// RemoveClassStudent 将学生移出班级
+func RemoveClassStudent(c *gin.Context) {
+	class, ok := loadTeacherClass(c)
+	if !ok {
+		return
+	}
+
+	studentID, ok := parseIDParam(c, "student_id")
+	if !ok {
+		return
+	}
+
+	result := database.DB.Where("class_id = ? AND student_id = ?", class.ID, studentID).Delete(&model.Enrollment{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除学生失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "学生不在班级中"})
+		return
+	}
+
+	if err := defaultDrillHandler.redis.RemoveFromClassRanking(c.Request.Context(), class.ID, studentID); err != nil {
+		// 排行榜会在下次重建时修正
+		fmt.Printf("更新班级排行榜失败: %v\n", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已移出班级"})
+}
+
+// GetClassStudentHistory 教师查看班级学生的历史记录
+func GetClassStudentHistory(c *gin.Context) {
+	studentID, ok := loadClassStudent(c)
+	if !ok {
+		return
+	}
+	respondHistory(c, studentID)
+}
+
+// GetClassStudentStats 教师查看班级学生的统计信息
+func GetClassStudentStats(c *gin.Context) {
+	studentID, ok := loadClassStudent(c)
+	if !ok {
+		return
+	}
+	respondStatistics(c, studentID)
+}
+
+// maxRosterFileSize 名单文件大小上限
+const maxRosterFileSize = 2 << 20
+
+// ImportRoster 教师批量导入学生名单（CSV/XLSX），返回登录凭证条
+// 查询参数 format=html 时返回可打印的凭证条页面
+func ImportRoster(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传名单文件"})
+		return
+	}
+	if fileHeader.Size > maxRosterFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "名单文件过大"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取名单文件失败"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxRosterFileSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取名单文件失败"})
+		return
+	}
+
+	rows, err := roster.Parse(fileHeader.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slips, rowErrs, err := roster.Import(database.DB, c.GetUint("user_id"), rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导入名单失败"})
+		return
+	}
+	if len(rowErrs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "名单校验失败，未导入任何学生", "row_errors": rowErrs})
+		return
+	}
+
+	if c.Query("format") == "html" {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := roster.RenderSlips(c.Writer, slips); err != nil {
+			c.Error(err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imported": len(slips),
+		"slips":    slips,
+	})
+}
+
+// loadTeacherClass 读取路径参数中的班级并校验是否属于当前教师，失败时直接写入响应
+func loadTeacherClass(c *gin.Context) (*model.Class, bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	var class model.Class
+	if err := database.DB.Where("id = ? AND teacher_id = ?", id, c.GetUint("user_id")).First(&class).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "班级不存在"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取班级失败"})
+		}
+		return nil, false
+	}
+
+	return &class, true
+}
+
+// loadClassStudent 校验路径参数中的学生属于当前教师的班级，返回学生ID
+func loadClassStudent(c *gin.Context) (uint, bool) {
+	class, ok := loadTeacherClass(c)
+	if !ok {
+		return 0, false
+	}
+
+	studentID, ok := parseIDParam(c, "student_id")
+	if !ok {
+		return 0, false
+	}
+
+	var count int64
+	if err := database.DB.Model(&model.Enrollment{}).
+		Where("class_id = ? AND student_id = ?", class.ID, studentID).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取学生失败"})
+		return 0, false
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "学生不在班级中"})
+		return 0, false
+	}
+
+	return studentID, true
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"calculator/internal/coin"
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// awardStreakCoins 答题后连续练习天数达到里程碑时发放金币（答对的金币在保存答题记录时发放）
+// 每条流水都有唯一来源，按里程碑和日期记录，当天之后的答题会再次检查，本次跳过时不会漏发
+func awardStreakCoins(ctx context.Context, record *model.HistoryRecord, streak int) {
+	if reward := coin.StreakReward(streak); reward > 0 {
+		date, _, _ := goalDay(record.CreatedAt, defaultDrillHandler.redis.UserLocation(ctx, record.UserID))
+		reference := fmt.Sprintf("%dd:%s", streak, date)
+		if err := coin.Earn(database.DB, record.UserID, reward, model.CoinReasonStreak, reference); err != nil {
+			fmt.Printf("发放连续练习金币失败: %v\n", err)
+		}
+	}
+}
+
+// GetCoins 获取当前用户的金币余额和流水
+func GetCoins(c *gin.Context) {
+	respondCoins(c, c.GetUint("user_id"))
+}
+
+// GetChildCoins 家长查看孩子的金币流水
+func GetChildCoins(c *gin.Context) {
+	studentID, ok := loadChild(c)
+	if !ok {
+		return
+	}
+	respondCoins(c, studentID)
+}
+
+// GetClassStudentCoins 教师查看学生的金币流水
+func GetClassStudentCoins(c *gin.Context) {
+	studentID, ok := loadClassStudent(c)
+	if !ok {
+		return
+	}
+	respondCoins(c, studentID)
+}
+
+// respondCoins 返回指定用户的金币余额、账目核对结果和分页的流水（从新到旧）
+func respondCoins(c *gin.Context, userID uint) {
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	audit, err := coin.Verify(database.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取金币余额失败"})
+		return
+	}
+
+	var total int64
+	if err := database.DB.Model(&model.CoinTransaction{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取金币流水失败"})
+		return
+	}
+
+	var transactions []model.CoinTransaction
+	if err := database.DB.Where("user_id = ?", userID).Order("id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取金币流水失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"balance":      audit.Balance,
+		"audit":        audit,
+		"transactions": transactions,
+		"total":        total,
+		"page":         page,
+		"page_size":    pageSize,
+	})
+}
+
+// shopItem 装扮及当前用户的拥有情况
+type shopItem struct {
+	coin.Item
+	Owned    bool `json:"owned"`
+	Equipped bool `json:"equipped"`
+}
+
+// GetShopItems 获取可兑换的装扮及当前用户的拥有情况
+func GetShopItems(c *gin.Context) {
+	var owned []model.UserItem
+	if err := database.DB.Where("user_id = ?", c.GetUint("user_id")).Find(&owned).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取装扮失败"})
+		return
+	}
+	ownedItems := make(map[string]model.UserItem, len(owned))
+	for _, item := range owned {
+		ownedItems[item.ItemCode] = item
+	}
+
+	items := make([]shopItem, 0, len(coin.Catalog))
+	for _, item := range coin.Catalog {
+		userItem, ok := ownedItems[item.Code]
+		items = append(items, shopItem{Item: item, Owned: ok, Equipped: userItem.Equipped})
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// PurchaseItem 用金币兑换装扮
+func PurchaseItem(c *gin.Context) {
+	item, ok := coin.FindItem(c.Param("code"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "装扮不存在"})
+		return
+	}
+
+	owned, err := coin.Purchase(database.DB, c.GetUint("user_id"), item)
+	if err != nil {
+		switch {
+		case errors.Is(err, coin.ErrAlreadyOwned):
+			c.JSON(http.StatusConflict, gin.H{"error": "已拥有该装扮"})
+		case errors.Is(err, coin.ErrInsufficientBalance):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "金币余额不足"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "兑换装扮失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, owned)
+}
+
+// EquipItem 使用已兑换的装扮，同类装扮同时只能使用一个
+func EquipItem(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	code := c.Param("code")
+
+	var owned model.UserItem
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND item_code = ?", userID, code).First(&owned).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.UserItem{}).
+			Where("user_id = ? AND category = ? AND item_code <> ?", userID, owned.Category, code).
+			Update("equipped", false).Error; err != nil {
+			return err
+		}
+		owned.Equipped = true
+		return tx.Model(&owned).Update("equipped", true).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "未拥有该装扮"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "使用装扮失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, owned)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"calculator/internal/coin"
 	"calculator/internal/database"
 	"calculator/internal/drill"
 	"calculator/internal/model"
@@ -9,9 +10,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 const (
@@ -21,58 +25,203 @@ const (
 	weeklyRankKey     = "rank:weekly"
 	// 时间衰减因子（24小时）
 	timeDecayFactor = 24 * time.Hour
+	// 单题限时的宽限时间，抵消网络延迟
+	questionTimeLimitGrace = time.Second
 )
 
+// questionTimeLimitEnv 各难度默认单题限时的环境变量（秒），未设置或为0表示不限时
+var questionTimeLimitEnv = map[drill.Difficulty]string{
+	drill.Easy:   "QUESTION_TIME_LIMIT_EASY",
+	drill.Medium: "QUESTION_TIME_LIMIT_MEDIUM",
+	drill.Hard:   "QUESTION_TIME_LIMIT_HARD",
+}
+
+// defaultQuestionTimeLimit 返回难度对应的默认单题限时（秒），0 表示不限时
+func defaultQuestionTimeLimit(difficulty drill.Difficulty) int {
+	seconds, err := strconv.Atoi(os.Getenv(questionTimeLimitEnv[difficulty]))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
 // 包级别默认 handler 实例，供路由直接调用
 var defaultDrillHandler = &DrillHandler{
 	generator: drill.NewGenerator(),
 	redis:     redis.NewRedis(),
 }
 
+// UseRedis 设置 handlers 使用的 Redis 客户端，应在启动服务前调用，使整个进程共用一个客户端
+func UseRedis(r *redis.Redis) {
+	defaultDrillHandler.redis = r
+}
+
 // RegisterRoutes 注册所有路由
 func RegisterRoutes(r *gin.Engine) {
 	// 注册热度排行榜路由
 	r.GET("/api/drill/rankings", GetHotRanking)
 }
 
-// GetQuestion 获取一道新题目
-func GetQuestion(c *gin.Context) {
-	difficultyStr := c.DefaultQuery("difficulty", "easy")
-	var difficulty drill.Difficulty
+// issuedQuestion 已发放并存储在 Redis 中的题目
+type issuedQuestion struct {
+	drill.Question
+	UserID    uint      `json:"user_id,omitempty"`
+	SessionID uint      `json:"session_id,omitempty"` // 所属练习会话，普通练习为 0
+	SprintID  uint      `json:"sprint_id,omitempty"`  // 所属限时冲刺，普通练习为 0
+	TimeLimit int       `json:"time_limit,omitempty"` // 单题限时（秒），0 表示不限时
+	IssuedAt  time.Time `json:"issued_at"`
+}
 
-	switch difficultyStr {
-	case "easy":
-		difficulty = drill.Easy
-	case "medium":
-		difficulty = drill.Medium
-	case "hard":
-		difficulty = drill.Hard
-	default:
-		difficulty = drill.Easy
+// timedOut 判断答案是否在限时之后提交
+func (q *issuedQuestion) timedOut(answeredAt time.Time) bool {
+	if q.TimeLimit <= 0 || q.IssuedAt.IsZero() {
+		return false
 	}
+	deadline := q.IssuedAt.Add(time.Duration(q.TimeLimit)*time.Second + questionTimeLimitGrace)
+	return answeredAt.After(deadline)
+}
+
+// questionKey 返回题目在 Redis 中的 key
+func questionKey(questionID int64) string {
+	return fmt.Sprintf("%s%d", redis.QuestionKeyPrefix, questionID)
+}
 
-	question := defaultDrillHandler.generator.Generate(difficulty)
+// issueQuestion 按难度和题型组合（为空表示全部题型）生成一道题目并存入 Redis，返回题目ID
+// owner 中的用户、会话等归属信息会随题目一起保存，未指定单题限时时使用难度的默认限时
+func issueQuestion(ctx context.Context, difficulty drill.Difficulty, templates []string, owner issuedQuestion) (int64, *issuedQuestion, error) {
+	question := defaultDrillHandler.generator.GenerateFrom(difficulty, templates)
 
 	// 生成一个唯一的题目ID，包含时间戳和用户ID
-	userID := int64(c.GetUint("user_id"))
 	timestamp := time.Now().UnixNano()
 	// 将用户ID和时间戳组合成一个唯一的ID
-	questionID := (userID * 1000000000000) + (timestamp % 1000000000000)
+	questionID := (int64(owner.UserID) * 1000000000000) + (timestamp % 1000000000000)
+
+	issued := &owner
+	issued.Question = question
+	issued.IssuedAt = time.Now()
+	if issued.TimeLimit == 0 {
+		issued.TimeLimit = defaultQuestionTimeLimit(difficulty)
+	}
 
 	// 将题目序列化为JSON
-	questionJSON, err := json.Marshal(question)
+	questionJSON, err := json.Marshal(issued)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "题目序列化失败"})
-		return
+		return 0, nil, fmt.Errorf("题目序列化失败: %v", err)
 	}
 
 	// 存储题目到Redis，设置30分钟过期时间
-	ctx := context.Background()
 	err = defaultDrillHandler.redis.Client.Set(ctx,
-		fmt.Sprintf("%s%d", redis.QuestionKeyPrefix, questionID),
+		questionKey(questionID),
 		questionJSON,
 		30*time.Minute,
 	).Err()
+	if err != nil {
+		return 0, nil, fmt.Errorf("保存题目失败: %v", err)
+	}
+
+	return questionID, issued, nil
+}
+
+// loadQuestion 从 Redis 读取已发放的题目
+func loadQuestion(ctx context.Context, questionID int64) (*issuedQuestion, error) {
+	questionJSON, err := defaultDrillHandler.redis.Client.Get(ctx, questionKey(questionID)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var question issuedQuestion
+	if err := json.Unmarshal(questionJSON, &question); err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
+// recordAnswer 判题并保存历史记录，同时更新用户热度值、每日练习记录，并提交后台处理每日目标和成就
+// 超过单题限时的答案记为超时（按错误处理），不增加热度
+func recordAnswer(ctx context.Context, userID uint, questionID int64, question *issuedQuestion, answer int) (*model.HistoryRecord, error) {
+	now := time.Now()
+	timedOut := question.timedOut(now)
+
+	// 判断答案是否正确，超时一律记为错误
+	isCorrect := answer == question.Answer && !timedOut
+
+	// 计算用时（旧数据没有发放时间时记为0）
+	var timeSpent float64
+	if !question.IssuedAt.IsZero() {
+		timeSpent = now.Sub(question.IssuedAt).Seconds()
+	}
+
+	// 创建历史记录
+	history := model.HistoryRecord{
+		UserID:           userID,
+		QuestionID:       fmt.Sprintf("%d", questionID),
+		Question_content: question.Expression,
+		UserAnswer:       answer,
+		CorrectAnswer:    question.Answer,
+		IsCorrect:        isCorrect,
+		TimedOut:         timedOut,
+		Difficulty:       question.Difficulty.String(),
+		TimeSpent:        timeSpent,
+		Source:           model.HistorySourceServer,
+	}
+	if question.SessionID != 0 {
+		sessionID := question.SessionID
+		history.SessionID = &sessionID
+	}
+	if question.SprintID != 0 {
+		sprintID := question.SprintID
+		history.SprintID = &sprintID
+	}
+
+	// 答题金币与历史记录在同一事务中保存，答对的题目一定发放，同一道题只发放一次
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+		if !isCorrect {
+			return nil
+		}
+		return coin.Earn(tx, userID, coin.AnswerReward(history.Difficulty), model.CoinReasonAnswer, "question:"+history.QuestionID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存历史记录失败: %v", err)
+	}
+
+	// 更新用户热度值，超时的答案不计热度
+	if !timedOut {
+		if err := defaultDrillHandler.redis.UpdateUserHotScore(ctx, &history); err != nil {
+			// 热度更新失败不影响答题结果
+			fmt.Printf("更新热度失败: %v\n", err)
+		}
+	}
+
+	// 记录每日练习，用于连续练习天数和日历热力图
+	if err := defaultDrillHandler.redis.RecordActivity(ctx, &history); err != nil {
+		fmt.Printf("记录每日练习失败: %v\n", err)
+	}
+
+	// 后台更新每日目标进度并检查成就，不影响答题响应时间
+	defaultAnswerEvents.publish(&history)
+
+	return &history, nil
+}
+
+// answerMessage 生成答题反馈信息
+func answerMessage(record *model.HistoryRecord) string {
+	if record.TimedOut {
+		return fmt.Sprintf("答题超时，正确答案是：%d", record.CorrectAnswer)
+	}
+	if record.IsCorrect {
+		return "回答正确！"
+	}
+	return fmt.Sprintf("回答错误，正确答案是：%d", record.CorrectAnswer)
+}
+
+// GetQuestion 获取一道新题目
+func GetQuestion(c *gin.Context) {
+	difficulty, _ := drill.ParseDifficulty(c.DefaultQuery("difficulty", "easy"))
+
+	questionID, question, err := issueQuestion(c.Request.Context(), difficulty, nil, issuedQuestion{UserID: c.GetUint("user_id")})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存题目失败"})
 		return
@@ -82,17 +231,16 @@ func GetQuestion(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"id":         questionID,          // 题目ID
 		"question":   question.Expression, // 题目表达式
-		"difficulty": difficultyStr,       // 难度
+		"difficulty": difficulty.String(), // 难度
+		"time_limit": question.TimeLimit,  // 单题限时（秒），0 表示不限时
 	})
 }
 
 // SubmitAnswer 提交答案
 func SubmitAnswer(c *gin.Context) {
 	var req struct {
-		QuestionID int64  `json:"question_id" binding:"required"`
-		Answer     *int   `json:"answer" binding:"required"`
-		Question   string `json:"question" binding:"required"`
-		Difficulty string `json:"difficulty" binding:"required"`
+		QuestionID int64 `json:"question_id" binding:"required"`
+		Answer     *int  `json:"answer" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -100,77 +248,75 @@ func SubmitAnswer(c *gin.Context) {
 		return
 	}
 
-	// 从Redis获取题目
-	ctx := context.Background()
-	questionJSON, err := defaultDrillHandler.redis.Client.Get(ctx, fmt.Sprintf("%s%d", redis.QuestionKeyPrefix, req.QuestionID)).Bytes()
-	if err != nil {
+	// 从Redis获取题目，只能提交发给自己的题目
+	ctx := c.Request.Context()
+	userID := c.GetUint("user_id")
+	question, err := loadQuestion(ctx, req.QuestionID)
+	if err != nil || question.UserID != userID {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "题目不存在或已过期"})
 		return
 	}
 
-	// 反序列化题目
-	var question drill.Question
-	if err := json.Unmarshal(questionJSON, &question); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "题目数据无效"})
+	// 练习会话和冲刺中的题目需要通过各自的接口提交
+	if question.SessionID != 0 || question.SprintID != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该题目属于练习或冲刺，请通过对应接口提交答案"})
 		return
 	}
 
-	// 判断答案是否正确
-	isCorrect := *req.Answer == question.Answer
-
-	// 创建历史记录
-	history := model.HistoryRecord{
-		UserID:           c.GetUint("user_id"),
-		QuestionID:       fmt.Sprintf("%d", req.QuestionID),
-		Question_content: req.Question,
-		UserAnswer:       *req.Answer,
-		CorrectAnswer:    question.Answer,
-		IsCorrect:        isCorrect,
-		Difficulty:       req.Difficulty,
-		TimeSpent:        0, // 暂时不记录用时
+	// 每道题只能作答一次
+	deleted, err := defaultDrillHandler.redis.Client.Del(ctx, questionKey(req.QuestionID)).Result()
+	if err != nil || deleted == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "题目已作答"})
+		return
 	}
 
-	if err := database.DB.Create(&history).Error; err != nil {
+	record, err := recordAnswer(ctx, userID, req.QuestionID, question, *req.Answer)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存历史记录失败"})
 		return
 	}
 
-	// 更新用户热度值
-	if err := defaultDrillHandler.redis.UpdateUserHotScore(ctx, c.GetUint("user_id"), isCorrect); err != nil {
-		// 热度更新失败不影响答题结果
-		fmt.Printf("更新热度失败: %v\n", err)
-	}
-
-	// 返回结果
-	message := "回答正确！"
-	if !isCorrect {
-		message = fmt.Sprintf("回答错误，正确答案是：%d", question.Answer)
-	}
-
 	c.JSON(http.StatusOK, gin.H{
-		"correct": isCorrect,
-		"message": message,
+		"correct":   record.IsCorrect,
+		"timed_out": record.TimedOut,
+		"message":   answerMessage(record),
 	})
 }
 
 // GetHotRanking 获取热度排行榜
 func GetHotRanking(c *gin.Context) {
-	// 获取排行榜类型（小时榜/日榜）
-	rankType := c.DefaultQuery("type", "hourly")
-	if rankType != "hourly" && rankType != "daily" {
+	// 获取排行榜周期（小时/日/周/月/总榜，或最近24小时/7天的滚动窗口）
+	rankType := c.DefaultQuery("type", redis.PeriodHourly)
+	if !redis.ValidRankPeriod(rankType) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的排行榜类型"})
 		return
 	}
 
+	// 班级或学校范围的排行榜需要校验权限
+	scope, ok := parseRankScope(c)
+	if !ok {
+		return
+	}
+
+	// 分页参数，默认返回前10名
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
 	// 获取排行榜数据
-	rankings, err := defaultDrillHandler.redis.GetHotRanking(c.Request.Context(), rankType, 10)
+	offset := int64((page - 1) * pageSize)
+	rankings, total, err := defaultDrillHandler.redis.GetHotRanking(c.Request.Context(), scope, rankType, offset, int64(pageSize))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取排行榜失败: %v", err)})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"rankings": rankings,
+		"rankings":  rankings,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
 	})
 }
 
@@ -0,0 +1,521 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/drill"
+	"calculator/internal/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errExamRetakeDenied 已完成考试且不允许重考
+var errExamRetakeDenied = errors.New("exam retake denied")
+
+const (
+	// 每份试卷最大题数
+	maxExamQuestionCount = 100
+	// 考试最长限时
+	maxExamTimeLimit = 2 * time.Hour
+)
+
+// examPaperView 学生可见的试卷（不含答案）
+type examPaperView struct {
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Difficulty  string     `json:"difficulty"`
+	TimeLimit   int        `json:"time_limit"`
+	AllowRetake bool       `json:"allow_retake"`
+	Questions   []examItem `json:"questions,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// examItem 学生可见的题目
+type examItem struct {
+	Index      int    `json:"index"`
+	Expression string `json:"expression"`
+}
+
+// newExamPaperView 构造不含答案的试卷，withQuestions 为 false 时不返回题目
+func newExamPaperView(paper *model.ExamPaper, withQuestions bool) examPaperView {
+	view := examPaperView{
+		ID:          paper.ID,
+		Title:       paper.Title,
+		Difficulty:  paper.Difficulty,
+		TimeLimit:   paper.TimeLimit,
+		AllowRetake: paper.AllowRetake,
+		CreatedAt:   paper.CreatedAt,
+	}
+	if withQuestions {
+		view.Questions = make([]examItem, 0, len(paper.Questions))
+		for _, q := range paper.Questions {
+			view.Questions = append(view.Questions, examItem{Index: q.Index, Expression: q.Expression})
+		}
+	}
+	return view
+}
+
+// CreateExamPaper 教师创建试卷，题目在创建时生成并固定
+func CreateExamPaper(c *gin.Context) {
+	var req struct {
+		Title         string `json:"title" binding:"required"`
+		Difficulty    string `json:"difficulty"`
+		QuestionCount int    `json:"question_count" binding:"required"`
+		TimeLimit     int    `json:"time_limit" binding:"required"` // 秒
+		AllowRetake   bool   `json:"allow_retake"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供完整的试卷信息"})
+		return
+	}
+
+	if req.Difficulty == "" {
+		req.Difficulty = "easy"
+	}
+	difficulty, ok := drill.ParseDifficulty(req.Difficulty)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的难度"})
+		return
+	}
+	if req.QuestionCount <= 0 || req.QuestionCount > maxExamQuestionCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("题数必须在1到%d之间", maxExamQuestionCount)})
+		return
+	}
+	if req.TimeLimit <= 0 || time.Duration(req.TimeLimit)*time.Second > maxExamTimeLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的考试时长"})
+		return
+	}
+
+	generated := defaultDrillHandler.generator.GenerateSet(difficulty, req.QuestionCount)
+	questions := make(model.ExamQuestions, 0, len(generated))
+	for i, q := range generated {
+		questions = append(questions, model.ExamQuestion{
+			Index:      i + 1,
+			Expression: q.Expression,
+			Answer:     q.Answer,
+		})
+	}
+
+	paper := model.ExamPaper{
+		TeacherID:   c.GetUint("user_id"),
+		Title:       req.Title,
+		Difficulty:  difficulty.String(),
+		TimeLimit:   req.TimeLimit,
+		AllowRetake: req.AllowRetake,
+		Questions:   questions,
+	}
+
+	if err := database.DB.Create(&paper).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建试卷失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, paper)
+}
+
+// studentTeacherIDs 返回学生所在班级的教师，用于限定学生可见的试卷
+func studentTeacherIDs(studentID uint) *gorm.DB {
+	return database.DB.Model(&model.Class{}).
+		Select("classes.teacher_id").
+		Joins("JOIN enrollments ON enrollments.class_id = classes.id").
+		Where("enrollments.student_id = ?", studentID)
+}
+
+// GetExamPapers 获取试卷列表，教师只看到自己创建的试卷，学生只看到自己所在班级的教师创建的试卷
+func GetExamPapers(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	query := database.DB.Order("created_at DESC")
+	isTeacher := c.GetString("role") == model.RoleTeacher
+	if isTeacher {
+		query = query.Where("teacher_id = ?", userID)
+	} else {
+		query = query.Where("teacher_id IN (?)", studentTeacherIDs(userID))
+	}
+
+	var papers []model.ExamPaper
+	if err := query.Find(&papers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取试卷列表失败"})
+		return
+	}
+
+	if isTeacher {
+		c.JSON(http.StatusOK, papers)
+		return
+	}
+
+	views := make([]examPaperView, 0, len(papers))
+	for i := range papers {
+		views = append(views, newExamPaperView(&papers[i], false))
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// GetExamPaper 获取试卷详情，学生只能在考试中看到题目且看不到答案
+func GetExamPaper(c *gin.Context) {
+	paper, ok := loadExamPaper(c)
+	if !ok {
+		return
+	}
+
+	if c.GetString("role") == model.RoleTeacher && paper.TeacherID == c.GetUint("user_id") {
+		c.JSON(http.StatusOK, paper)
+		return
+	}
+
+	c.JSON(http.StatusOK, newExamPaperView(paper, false))
+}
+
+// GetExamAttempts 教师查看某份试卷的全部作答记录
+func GetExamAttempts(c *gin.Context) {
+	paper, ok := loadExamPaper(c)
+	if !ok {
+		return
+	}
+
+	if paper.TeacherID != c.GetUint("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只能查看自己创建的试卷"})
+		return
+	}
+
+	var attempts []model.ExamAttempt
+	if err := database.DB.Where("paper_id = ?", paper.ID).Order("started_at DESC").Find(&attempts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取作答记录失败"})
+		return
+	}
+
+	// 已超时但尚未交卷的作答在查看时自动判分
+	for i := range attempts {
+		if attempts[i].Status == model.ExamStatusInProgress && !time.Now().Before(attempts[i].Deadline) {
+			graded, err := gradeExamAttempt(attempts[i].ID, model.ExamStatusTimedOut)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "判分失败"})
+				return
+			}
+			attempts[i] = *graded
+		}
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+// StartExam 学生开始考试，已有进行中的作答时直接返回该作答
+func StartExam(c *gin.Context) {
+	paper, ok := loadExamPaper(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetUint("user_id")
+
+	var attempt model.ExamAttempt
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// 锁定学生的用户记录，同一学生并发开始考试时依次检查最近一次作答，不会同时创建两次作答
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&model.User{}, userID).Error; err != nil {
+			return err
+		}
+
+		var latest model.ExamAttempt
+		err := tx.Where("paper_id = ? AND user_id = ?", paper.ID, userID).Order("id DESC").First(&latest).Error
+		switch {
+		case err == nil:
+			if latest.Status == model.ExamStatusInProgress {
+				if time.Now().Before(latest.Deadline) {
+					attempt = latest
+					return nil
+				}
+				if _, err := gradeExamAttempt(latest.ID, model.ExamStatusTimedOut); err != nil {
+					return fmt.Errorf("判分失败: %v", err)
+				}
+			}
+			if !paper.AllowRetake && !latest.RetakeAllowed {
+				return errExamRetakeDenied
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		now := time.Now()
+		attempt = model.ExamAttempt{
+			PaperID:   paper.ID,
+			UserID:    userID,
+			Status:    model.ExamStatusInProgress,
+			Answers:   model.ExamAnswers{},
+			Total:     len(paper.Questions),
+			StartedAt: now,
+			Deadline:  now.Add(time.Duration(paper.TimeLimit) * time.Second),
+		}
+		return tx.Create(&attempt).Error
+	})
+	if errors.Is(err, errExamRetakeDenied) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "已完成该考试，不能重考"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "开始考试失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attempt": attempt, "paper": newExamPaperView(paper, true)})
+}
+
+// GetExamAttempt 查看作答记录，交卷前不返回对错信息
+func GetExamAttempt(c *gin.Context) {
+	attempt, ok := loadExamAttempt(c)
+	if !ok {
+		return
+	}
+
+	if attempt.Status == model.ExamStatusInProgress {
+		if time.Now().Before(attempt.Deadline) {
+			c.JSON(http.StatusOK, gin.H{"attempt": attempt})
+			return
+		}
+		graded, err := gradeExamAttempt(attempt.ID, model.ExamStatusTimedOut)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "判分失败"})
+			return
+		}
+		attempt = graded
+	}
+
+	var paper model.ExamPaper
+	if err := database.DB.First(&paper, attempt.PaperID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取试卷失败"})
+		return
+	}
+
+	// 交卷后返回完整试卷用于查看错题
+	c.JSON(http.StatusOK, gin.H{"attempt": attempt, "paper": paper})
+}
+
+// SaveExamAnswers 保存考试答案（可多次保存），不返回对错
+func SaveExamAnswers(c *gin.Context) {
+	var req struct {
+		Answers map[int]int `json:"answers" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	attempt, ok := loadExamAttempt(c)
+	if !ok {
+		return
+	}
+
+	if attempt.Status != model.ExamStatusInProgress {
+		c.JSON(http.StatusConflict, gin.H{"error": "考试已结束"})
+		return
+	}
+	if !time.Now().Before(attempt.Deadline) {
+		graded, err := gradeExamAttempt(attempt.ID, model.ExamStatusTimedOut)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "判分失败"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "考试时间已到，已自动交卷", "attempt": graded})
+		return
+	}
+
+	if attempt.Answers == nil {
+		attempt.Answers = model.ExamAnswers{}
+	}
+	for index, answer := range req.Answers {
+		if index < 1 || index > attempt.Total {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的题目序号: %d", index)})
+			return
+		}
+		attempt.Answers[index] = answer
+	}
+
+	result := database.DB.Model(&model.ExamAttempt{}).
+		Where("id = ? AND status = ?", attempt.ID, model.ExamStatusInProgress).
+		Update("answers", attempt.Answers)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存答案失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "考试已结束"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "答案已保存", "answered": len(attempt.Answers)})
+}
+
+// SubmitExam 交卷并判分
+func SubmitExam(c *gin.Context) {
+	attempt, ok := loadExamAttempt(c)
+	if !ok {
+		return
+	}
+
+	if attempt.Status != model.ExamStatusInProgress {
+		c.JSON(http.StatusOK, attempt)
+		return
+	}
+
+	status := model.ExamStatusSubmitted
+	if !time.Now().Before(attempt.Deadline) {
+		status = model.ExamStatusTimedOut
+	}
+
+	graded, err := gradeExamAttempt(attempt.ID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "判分失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, graded)
+}
+
+// AllowExamRetake 教师允许学生重考
+func AllowExamRetake(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var attempt model.ExamAttempt
+	if err := database.DB.First(&attempt, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "作答记录不存在"})
+		return
+	}
+
+	var paper model.ExamPaper
+	if err := database.DB.First(&paper, attempt.PaperID).Error; err != nil || paper.TeacherID != c.GetUint("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只能管理自己创建的试卷"})
+		return
+	}
+
+	if err := database.DB.Model(&attempt).Update("retake_allowed", true).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "设置重考失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已允许重考"})
+}
+
+// gradeExamAttempt 对作答判分，写入历史记录，并将作答标记为指定的结束状态
+// 已结束的作答不会重复判分
+func gradeExamAttempt(attemptID uint, status string) (*model.ExamAttempt, error) {
+	var attempt model.ExamAttempt
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&attempt, attemptID).Error; err != nil {
+			return err
+		}
+		if attempt.Status != model.ExamStatusInProgress {
+			return nil
+		}
+
+		var paper model.ExamPaper
+		if err := tx.First(&paper, attempt.PaperID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		// 考试不记录每道题的用时，按作答时长平均分配到已作答的题目
+		elapsed := now.Sub(attempt.StartedAt)
+		if attempt.Deadline.Before(now) {
+			elapsed = attempt.Deadline.Sub(attempt.StartedAt)
+		}
+		correct := 0
+		records := make([]model.HistoryRecord, 0, len(paper.Questions))
+		for _, q := range paper.Questions {
+			answer, answered := attempt.Answers[q.Index]
+			isCorrect := answered && answer == q.Answer
+			if isCorrect {
+				correct++
+			}
+			if !answered {
+				continue
+			}
+			attemptID := attempt.ID
+			records = append(records, model.HistoryRecord{
+				UserID:           attempt.UserID,
+				QuestionID:       fmt.Sprintf("exam:%d:%d", paper.ID, q.Index),
+				Question_content: q.Expression,
+				UserAnswer:       answer,
+				CorrectAnswer:    q.Answer,
+				IsCorrect:        isCorrect,
+				Difficulty:       paper.Difficulty,
+				ExamAttemptID:    &attemptID,
+				Source:           model.HistorySourceServer,
+			})
+		}
+
+		if len(records) > 0 {
+			timeSpent := elapsed.Seconds() / float64(len(records))
+			for i := range records {
+				records[i].TimeSpent = timeSpent
+			}
+			if err := tx.Create(&records).Error; err != nil {
+				return err
+			}
+		}
+
+		attempt.Status = status
+		attempt.Correct = correct
+		attempt.Score = 0
+		if attempt.Total > 0 {
+			attempt.Score = float64(correct) / float64(attempt.Total) * 100
+		}
+		attempt.SubmittedAt = &now
+
+		return tx.Save(&attempt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// loadExamPaper 读取路径参数中的试卷，只能读取自己创建的或自己所在班级的教师创建的试卷，失败时直接写入响应
+func loadExamPaper(c *gin.Context) (*model.ExamPaper, bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	userID := c.GetUint("user_id")
+	var paper model.ExamPaper
+	if err := database.DB.Where("id = ?", id).
+		Where("teacher_id = ? OR teacher_id IN (?)", userID, studentTeacherIDs(userID)).
+		First(&paper).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "试卷不存在"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取试卷失败"})
+		}
+		return nil, false
+	}
+
+	return &paper, true
+}
+
+// loadExamAttempt 读取路径参数中的作答记录并校验归属，失败时直接写入响应
+func loadExamAttempt(c *gin.Context) (*model.ExamAttempt, bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	var attempt model.ExamAttempt
+	if err := database.DB.Where("id = ? AND user_id = ?", id, c.GetUint("user_id")).First(&attempt).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "作答记录不存在"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取作答记录失败"})
+		}
+		return nil, false
+	}
+
+	return &attempt, true
+}
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/drill"
+	"calculator/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// 每日答题数目标上限
+	maxGoalQuestions = 1000
+	// 每日练习分钟数目标上限
+	maxGoalMinutes = 600
+	// 完成记录默认天数
+	defaultGoalHistoryDays = 30
+	// 完成记录最多天数
+	maxGoalHistoryDays = 365
+	// goalDateLayout 目标记录的日期格式
+	goalDateLayout = "2006-01-02"
+	// 分钟数目标中每道题最多计入的秒数，难度没有单题限时或限时更长时使用
+	maxGoalAnswerSeconds = 300
+)
+
+// goalAnswerSeconds 返回分钟数目标中该难度每道题最多计入的秒数：单题限时，不超过 maxGoalAnswerSeconds
+// 避免答题页面长时间挂起时一道题计入大量时间
+func goalAnswerSeconds(difficulty drill.Difficulty) int {
+	limit := defaultQuestionTimeLimit(difficulty)
+	if limit == 0 || limit > maxGoalAnswerSeconds {
+		return maxGoalAnswerSeconds
+	}
+	return limit
+}
+
+// goalDay 返回 now 在 loc 时区所在的日期及当天的起止时间
+func goalDay(now time.Time, loc *time.Location) (string, time.Time, time.Time) {
+	local := now.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	return start.Format(goalDateLayout), start, start.AddDate(0, 0, 1)
+}
+
+// goalProgress 统计用户在 [start, until] 内的目标进度：答题数或按答题用时累计的分钟数
+// 只统计服务端判题保存的练习答题，客户端上报的记录和考试不计入
+func goalProgress(userID uint, goalType string, start, until time.Time) (int, error) {
+	query := database.DB.Model(&model.HistoryRecord{}).Scopes(model.CountedAnswers).
+		Where("user_id = ? AND created_at >= ? AND created_at <= ?", userID, start, until)
+
+	if goalType == model.GoalTypeMinutes {
+		var seconds float64
+		if err := query.Select("COALESCE(SUM(LEAST(time_spent, CASE difficulty WHEN ? THEN ? WHEN ? THEN ? ELSE ? END)), 0)",
+			drill.Medium.String(), goalAnswerSeconds(drill.Medium),
+			drill.Hard.String(), goalAnswerSeconds(drill.Hard),
+			goalAnswerSeconds(drill.Easy)).Scan(&seconds).Error; err != nil {
+			return 0, err
+		}
+		return int(seconds / 60), nil
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// loadDailyGoal 获取用户的每日目标，没有设置时返回 nil
+func loadDailyGoal(userID uint) (*model.DailyGoal, error) {
+	var goal model.DailyGoal
+	if err := database.DB.Where("user_id = ?", userID).First(&goal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &goal, nil
+}
+
+// firstOrCreateGoalRecord 获取用户在 date 的目标记录，第一次创建时保存当时的目标
+func firstOrCreateGoalRecord(goal *model.DailyGoal, date string) (*model.GoalRecord, error) {
+	var record model.GoalRecord
+	if err := database.DB.Where(model.GoalRecord{UserID: goal.UserID, Date: date}).
+		Attrs(model.GoalRecord{Type: goal.Type, Target: goal.Target}).
+		FirstOrCreate(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// updateGoalProgress 答题后重新统计当天截至这道题的目标进度并保存
+// 答题事件按顺序处理，进度第一次达到目标的那道题的答题时间即为完成时间
+func updateGoalProgress(ctx context.Context, answer *model.HistoryRecord) {
+	if err := refreshGoalRecord(ctx, answer.UserID, answer.CreatedAt); err != nil {
+		fmt.Printf("更新每日目标进度失败: %v\n", err)
+	}
+}
+
+// refreshGoalRecord 重新统计用户当天截至 at 的目标进度并保存，用户没有设置目标时不处理
+func refreshGoalRecord(ctx context.Context, userID uint, at time.Time) error {
+	goal, err := loadDailyGoal(userID)
+	if err != nil || goal == nil {
+		return err
+	}
+
+	date, start, _ := goalDay(at, defaultDrillHandler.redis.UserLocation(ctx, userID))
+	record, err := firstOrCreateGoalRecord(goal, date)
+	if err != nil {
+		return err
+	}
+
+	progress, err := goalProgress(userID, record.Type, start, at)
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{"progress": progress}
+	if !record.Completed && progress >= record.Target {
+		updates["completed"] = true
+		updates["completed_at"] = at
+	}
+	return database.DB.Model(record).Updates(updates).Error
+}
+
+// reminderDue 判断当天是否应该提醒：设置了提醒时间、已过提醒时间、目标未完成且当天还没有提醒过
+func reminderDue(goal *model.DailyGoal, record *model.GoalRecord, now time.Time, loc *time.Location) bool {
+	if goal.ReminderTime == "" || record.Completed || record.RemindedAt != nil {
+		return false
+	}
+	return now.In(loc).Format("15:04") >= goal.ReminderTime
+}
+
+// GetGoal 获取当前用户的每日目标和今天的进度
+func GetGoal(c *gin.Context) {
+	respondGoal(c, c.GetUint("user_id"))
+}
+
+// GetClassStudentGoal 教师查看学生的每日目标和今天的进度
+func GetClassStudentGoal(c *gin.Context) {
+	studentID, ok := loadClassStudent(c)
+	if !ok {
+		return
+	}
+	respondGoal(c, studentID)
+}
+
+// respondGoal 返回指定用户的每日目标、今天的进度和提醒状态，只读取已保存的记录
+// 今天还没有答题时返回进度为 0 的记录
+func respondGoal(c *gin.Context, userID uint) {
+	goal, err := loadDailyGoal(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取每日目标失败"})
+		return
+	}
+	if goal == nil {
+		c.JSON(http.StatusOK, gin.H{"goal": nil})
+		return
+	}
+
+	now := time.Now()
+	loc := defaultDrillHandler.redis.UserLocation(c.Request.Context(), userID)
+	date, _, _ := goalDay(now, loc)
+
+	record := model.GoalRecord{UserID: userID, Date: date, Type: goal.Type, Target: goal.Target}
+	if err := database.DB.Where("user_id = ? AND date = ?", userID, date).First(&record).Error; err != nil &&
+		!errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取每日目标失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"goal":         goal,
+		"today":        record,
+		"reminder_due": reminderDue(goal, &record, now, loc),
+	})
+}
+
+// SetGoal 学生设置自己的每日目标
+func SetGoal(c *gin.Context) {
+	saveGoal(c, c.GetUint("user_id"))
+}
+
+// SetClassStudentGoal 教师为学生设置每日目标
+func SetClassStudentGoal(c *gin.Context) {
+	studentID, ok := loadClassStudent(c)
+	if !ok {
+		return
+	}
+	saveGoal(c, studentID)
+}
+
+// saveGoal 校验并保存指定用户的每日目标，今天尚未完成时今天的记录同步使用新目标
+func saveGoal(c *gin.Context, userID uint) {
+	var req struct {
+		Type         string `json:"type" binding:"required"`
+		Target       int    `json:"target" binding:"required"`
+		ReminderTime string `json:"reminder_time"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if !model.ValidGoalType(req.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "目标类型只能是 questions 或 minutes"})
+		return
+	}
+	maxTarget := maxGoalQuestions
+	if req.Type == model.GoalTypeMinutes {
+		maxTarget = maxGoalMinutes
+	}
+	if req.Target < 1 || req.Target > maxTarget {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("目标必须在1到%d之间", maxTarget)})
+		return
+	}
+	if req.ReminderTime != "" {
+		if _, err := time.Parse("15:04", req.ReminderTime); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "提醒时间格式应为 HH:MM"})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	date, _, _ := goalDay(time.Now(), defaultDrillHandler.redis.UserLocation(ctx, userID))
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		goal := model.DailyGoal{UserID: userID}
+		if err := tx.Where(goal).FirstOrInit(&goal).Error; err != nil {
+			return err
+		}
+		goal.Type = req.Type
+		goal.Target = req.Target
+		goal.ReminderTime = req.ReminderTime
+		goal.SetBy = c.GetUint("user_id")
+		if err := tx.Save(&goal).Error; err != nil {
+			return err
+		}
+
+		// 已完成的记录保持不变
+		return tx.Model(&model.GoalRecord{}).
+			Where("user_id = ? AND date = ? AND completed = ?", userID, date, false).
+			Updates(map[string]interface{}{"type": req.Type, "target": req.Target}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存每日目标失败"})
+		return
+	}
+
+	respondGoal(c, userID)
+}
+
+// GetGoalHistory 获取当前用户最近 days 天的目标完成记录
+func GetGoalHistory(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultGoalHistoryDays)))
+	if err != nil || days < 1 || days > maxGoalHistoryDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("天数必须在1到%d之间", maxGoalHistoryDays)})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	_, start, _ := goalDay(time.Now(), defaultDrillHandler.redis.UserLocation(c.Request.Context(), userID))
+	since := start.AddDate(0, 0, 1-days).Format(goalDateLayout)
+
+	var records []model.GoalRecord
+	if err := database.DB.Where("user_id = ? AND date >= ?", userID, since).Order("date DESC").Find(&records).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取目标完成记录失败"})
+		return
+	}
+
+	completed := 0
+	for _, record := range records {
+		if record.Completed {
+			completed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"records":   records,
+		"completed": completed,
+		"since":     since,
+	})
+}
+
+// AcknowledgeGoalReminder 客户端展示提醒后调用，当天不再提醒
+func AcknowledgeGoalReminder(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	goal, err := loadDailyGoal(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存提醒状态失败"})
+		return
+	}
+	if goal == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "尚未设置每日目标"})
+		return
+	}
+
+	// 今天还没有答题时先创建当天的记录
+	date, _, _ := goalDay(time.Now(), defaultDrillHandler.redis.UserLocation(c.Request.Context(), userID))
+	record, err := firstOrCreateGoalRecord(goal, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存提醒状态失败"})
+		return
+	}
+
+	result := database.DB.Model(&model.GoalRecord{}).
+		Where("id = ? AND reminded_at IS NULL", record.ID).
+		Update("reminded_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存提醒状态失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已记录提醒"})
+}
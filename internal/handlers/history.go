@@ -11,7 +11,11 @@ import (
 
 // GetHistory 获取用户的历史记录
 func GetHistory(c *gin.Context) {
-	userID := c.GetUint("user_id")
+	respondHistory(c, c.GetUint("user_id"))
+}
+
+// respondHistory 按查询参数筛选指定用户的历史记录并写入响应
+func respondHistory(c *gin.Context, userID uint) {
 	difficulty := c.Query("difficulty")
 	date := c.Query("date")
 
@@ -40,17 +44,21 @@ func GetHistory(c *gin.Context) {
 
 // GetStatistics 获取用户的练习统计信息
 func GetStatistics(c *gin.Context) {
-	userID := c.GetUint("user_id")
+	respondStatistics(c, c.GetUint("user_id"))
+}
 
+// respondStatistics 统计指定用户的练习信息并写入响应
+func respondStatistics(c *gin.Context, userID uint) {
 	// 获取不同难度的题目数量
 	var stats struct {
-		TotalQuestions  int64   `json:"total_questions"`  // 去重后的总题数
-		EasyQuestions   int64   `json:"easy_questions"`   // 去重后的简单题数
-		MediumQuestions int64   `json:"medium_questions"` // 去重后的中等题数
-		HardQuestions   int64   `json:"hard_questions"`   // 去重后的难题数
-		TotalAttempts   int64   `json:"total_attempts"`   // 总答题次数
-		CorrectAnswers  int64   `json:"correct_answers"`  // 正确答题次数
-		Accuracy        float64 `json:"accuracy"`         // 正确率（正确次数/总次数）
+		TotalQuestions  int64   `json:"total_questions"`   // 去重后的总题数
+		EasyQuestions   int64   `json:"easy_questions"`    // 去重后的简单题数
+		MediumQuestions int64   `json:"medium_questions"`  // 去重后的中等题数
+		HardQuestions   int64   `json:"hard_questions"`    // 去重后的难题数
+		TotalAttempts   int64   `json:"total_attempts"`    // 总答题次数
+		CorrectAnswers  int64   `json:"correct_answers"`   // 正确答题次数
+		TimedOutAnswers int64   `json:"timed_out_answers"` // 超时答题次数
+		Accuracy        float64 `json:"accuracy"`          // 正确率（正确次数/总次数）
 	}
 
 	// 获取去重后的总题数
@@ -103,6 +111,14 @@ func GetStatistics(c *gin.Context) {
 		return
 	}
 
+	// 获取超时答题次数
+	if err := database.DB.Model(&model.HistoryRecord{}).
+		Where("user_id = ? AND timed_out = true", userID).
+		Count(&stats.TimedOutAnswers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计信息失败"})
+		return
+	}
+
 	// 计算正确率（使用总答题次数作为分母）
 	if stats.TotalAttempts > 0 {
 		stats.Accuracy = float64(stats.CorrectAnswers) / float64(stats.TotalAttempts) * 100
@@ -111,17 +127,35 @@ func GetStatistics(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
-// AddHistory 添加历史记录
+// AddHistory 添加客户端上报的历史记录
+// 只接受题目和作答内容，记录标记为客户端来源，不能关联练习、冲刺或考试
 func AddHistory(c *gin.Context) {
-	var record model.HistoryRecord
-	if err := c.ShouldBindJSON(&record); err != nil {
+	var req struct {
+		QuestionID      string  `json:"question_id" binding:"required"`
+		QuestionContent string  `json:"question_content" binding:"required"`
+		UserAnswer      int     `json:"user_answer"`
+		CorrectAnswer   int     `json:"correct_answer"`
+		Difficulty      string  `json:"difficulty" binding:"required"`
+		TimeSpent       float64 `json:"time_spent"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
 		return
 	}
 
-	record.UserID = c.GetUint("user_id")
-	record.CreatedAt = time.Now()
-	record.UpdatedAt = time.Now()
+	record := model.HistoryRecord{
+		UserID:           c.GetUint("user_id"),
+		QuestionID:       req.QuestionID,
+		Question_content: req.QuestionContent,
+		UserAnswer:       req.UserAnswer,
+		CorrectAnswer:    req.CorrectAnswer,
+		IsCorrect:        req.UserAnswer == req.CorrectAnswer,
+		Difficulty:       req.Difficulty,
+		TimeSpent:        req.TimeSpent,
+		Source:           model.HistorySourceClient,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
+	}
 
 	if err := database.DB.Create(&record).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存历史记录失败"})
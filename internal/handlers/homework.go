@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/drill"
+	"calculator/internal/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 学生作业状态
+const (
+	homeworkStatusNotStarted = "not_started"
+	homeworkStatusInProgress = "in_progress"
+	homeworkStatusCompleted  = "completed"
+)
+
+// homeworkProgress 学生的作业完成情况
+type homeworkProgress struct {
+	Status     string     `json:"status"`
+	SessionID  *uint      `json:"session_id,omitempty"`
+	Answered   int        `json:"answered"`
+	Score      int        `json:"score"`
+	Accuracy   float64    `json:"accuracy"`
+	Late       bool       `json:"late"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// newHomeworkProgress 根据作业对应的练习会话生成完成情况，session 为 nil 表示未开始
+func newHomeworkProgress(session *model.PracticeSession) homeworkProgress {
+	if session == nil {
+		return homeworkProgress{Status: homeworkStatusNotStarted}
+	}
+
+	progress := homeworkProgress{
+		Status:     homeworkStatusInProgress,
+		SessionID:  &session.ID,
+		Answered:   session.Answered,
+		Score:      session.Score,
+		Accuracy:   session.Accuracy,
+		Late:       session.Late,
+		FinishedAt: session.FinishedAt,
+	}
+	if session.Status == model.PracticeStatusFinished {
+		progress.Status = homeworkStatusCompleted
+	}
+	return progress
+}
+
+// CreateHomework 教师给班级布置作业
+func CreateHomework(c *gin.Context) {
+	var req struct {
+		Title         string     `json:"title" binding:"required"`
+		Difficulty    string     `json:"difficulty"`
+		QuestionCount int        `json:"question_count" binding:"required"`
+		Templates     []string   `json:"templates"` // 题型组合，不填表示该难度的全部题型
+		TimeLimit     int        `json:"time_limit"`
+		OpensAt       *time.Time `json:"opens_at"`
+		DueAt         time.Time  `json:"due_at" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供完整的作业信息"})
+		return
+	}
+
+	class, ok := loadTeacherClass(c)
+	if !ok {
+		return
+	}
+
+	if req.Difficulty == "" {
+		req.Difficulty = "easy"
+	}
+	difficulty, ok := drill.ParseDifficulty(req.Difficulty)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的难度"})
+		return
+	}
+	if !drill.ValidTemplates(difficulty, req.Templates) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无效的题型，%s 难度可选 %v", difficulty, drill.Templates[difficulty])})
+		return
+	}
+	if req.QuestionCount <= 0 || req.QuestionCount > maxPracticeQuestionCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("题数必须在1到%d之间", maxPracticeQuestionCount)})
+		return
+	}
+	if req.TimeLimit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的单题限时"})
+		return
+	}
+
+	opensAt := time.Now()
+	if req.OpensAt != nil {
+		opensAt = *req.OpensAt
+	}
+	if !req.DueAt.After(opensAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "截止时间必须晚于开放时间"})
+		return
+	}
+
+	homework := model.Homework{
+		ClassID:       class.ID,
+		TeacherID:     class.TeacherID,
+		Title:         req.Title,
+		Difficulty:    difficulty.String(),
+		QuestionCount: req.QuestionCount,
+		Templates:     req.Templates,
+		TimeLimit:     req.TimeLimit,
+		OpensAt:       opensAt,
+		DueAt:         req.DueAt,
+	}
+
+	if err := database.DB.Create(&homework).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "布置作业失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, homework)
+}
+
+// GetClassHomework 教师查看班级的作业列表
+func GetClassHomework(c *gin.Context) {
+	class, ok := loadTeacherClass(c)
+	if !ok {
+		return
+	}
+
+	var homework []model.Homework
+	if err := database.DB.Where("class_id = ?", class.ID).Order("due_at DESC").Find(&homework).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取作业列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, homework)
+}
+
+// GetHomeworkReport 教师查看作业的完成情况和成绩
+func GetHomeworkReport(c *gin.Context) {
+	class, ok := loadTeacherClass(c)
+	if !ok {
+		return
+	}
+
+	homeworkID, ok := parseIDParam(c, "homework_id")
+	if !ok {
+		return
+	}
+
+	var homework model.Homework
+	if err := database.DB.Where("id = ? AND class_id = ?", homeworkID, class.ID).First(&homework).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "作业不存在"})
+		return
+	}
+
+	var students []rosterStudent
+	if err := database.DB.Model(&model.User{}).
+		Select("users.id, users.username, users.name, users.student_no").
+		Joins("JOIN enrollments ON enrollments.student_id = users.id").
+		Where("enrollments.class_id = ?", class.ID).
+		Order("users.username").
+		Scan(&students).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取学生名单失败"})
+		return
+	}
+
+	var sessions []model.PracticeSession
+	if err := database.DB.Where("homework_id = ?", homework.ID).Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取作业记录失败"})
+		return
+	}
+	sessionByUser := make(map[uint]*model.PracticeSession, len(sessions))
+	for i := range sessions {
+		sessionByUser[sessions[i].UserID] = &sessions[i]
+	}
+
+	type studentReport struct {
+		rosterStudent
+		homeworkProgress
+	}
+
+	reports := make([]studentReport, 0, len(students))
+	var completed, late int
+	var totalAccuracy float64
+	for _, student := range students {
+		progress := newHomeworkProgress(sessionByUser[student.ID])
+		if progress.Status == homeworkStatusCompleted {
+			completed++
+			totalAccuracy += progress.Accuracy
+			if progress.Late {
+				late++
+			}
+		}
+		reports = append(reports, studentReport{rosterStudent: student, homeworkProgress: progress})
+	}
+
+	var averageAccuracy float64
+	if completed > 0 {
+		averageAccuracy = totalAccuracy / float64(completed)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"homework":         homework,
+		"student_count":    len(students),
+		"completed_count":  completed,
+		"late_count":       late,
+		"average_accuracy": averageAccuracy,
+		"students":         reports,
+	})
+}
+
+// GetMyHomework 学生查看自己所在班级的作业及完成情况
+func GetMyHomework(c *gin.Context) {
+	respondHomework(c, c.GetUint("user_id"))
+}
+
+// respondHomework 查询指定学生的作业及完成情况并写入响应
+func respondHomework(c *gin.Context, userID uint) {
+	var homework []model.Homework
+	if err := database.DB.
+		Joins("JOIN enrollments ON enrollments.class_id = homeworks.class_id").
+		Where("enrollments.student_id = ? AND homeworks.opens_at <= ?", userID, time.Now()).
+		Order("homeworks.due_at ASC").
+		Find(&homework).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取作业列表失败"})
+		return
+	}
+
+	var sessions []model.PracticeSession
+	if err := database.DB.Where("user_id = ? AND homework_id IS NOT NULL", userID).Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取作业记录失败"})
+		return
+	}
+	sessionByHomework := make(map[uint]*model.PracticeSession, len(sessions))
+	for i := range sessions {
+		sessionByHomework[*sessions[i].HomeworkID] = &sessions[i]
+	}
+
+	type homeworkItem struct {
+		model.Homework
+		Progress homeworkProgress `json:"progress"`
+		Overdue  bool             `json:"overdue"`
+	}
+
+	now := time.Now()
+	pending := make([]homeworkItem, 0)
+	completed := make([]homeworkItem, 0)
+	for _, hw := range homework {
+		progress := newHomeworkProgress(sessionByHomework[hw.ID])
+		item := homeworkItem{Homework: hw, Progress: progress, Overdue: now.After(hw.DueAt)}
+		if progress.Status == homeworkStatusCompleted {
+			completed = append(completed, item)
+		} else {
+			pending = append(pending, item)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pending":   pending,
+		"completed": completed,
+	})
+}
+
+// StartHomework 学生开始做作业，返回对应的练习会话，之后通过练习接口答题
+func StartHomework(c *gin.Context) {
+	homeworkID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userID := c.GetUint("user_id")
+
+	var homework model.Homework
+	if err := database.DB.
+		Joins("JOIN enrollments ON enrollments.class_id = homeworks.class_id").
+		Where("homeworks.id = ? AND enrollments.student_id = ?", homeworkID, userID).
+		First(&homework).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "作业不存在"})
+		return
+	}
+
+	if time.Now().Before(homework.OpensAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "作业尚未开放"})
+		return
+	}
+
+	// 每份作业只对应一次练习，重复开始时返回已有的练习
+	var session model.PracticeSession
+	err := database.DB.Where("user_id = ? AND homework_id = ?", userID, homework.ID).First(&session).Error
+	if err == nil {
+		c.JSON(http.StatusOK, gin.H{"session": session, "late": time.Now().After(homework.DueAt)})
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取作业记录失败"})
+		return
+	}
+
+	session = model.PracticeSession{
+		UserID:        userID,
+		Difficulty:    homework.Difficulty,
+		QuestionCount: homework.QuestionCount,
+		Templates:     homework.Templates,
+		TimeLimit:     homework.TimeLimit,
+		Status:        model.PracticeStatusInProgress,
+		HomeworkID:    &homework.ID,
+		StartedAt:     time.Now(),
+	}
+
+	// 并发开始时由唯一索引保证只创建一个练习，创建后统一读取实际保存的练习
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "开始作业失败"})
+		return
+	}
+	if err := database.DB.Where("user_id = ? AND homework_id = ?", userID, homework.ID).First(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "开始作业失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session, "late": time.Now().After(homework.DueAt)})
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"calculator/internal/redis"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateMyLinkCode 学生生成家长关联码
+func CreateMyLinkCode(c *gin.Context) {
+	respondLinkCode(c, c.GetUint("user_id"))
+}
+
+// CreateClassStudentLinkCode 教师为班级学生生成家长关联码
+func CreateClassStudentLinkCode(c *gin.Context) {
+	studentID, ok := loadClassStudent(c)
+	if !ok {
+		return
+	}
+	respondLinkCode(c, studentID)
+}
+
+// respondLinkCode 为学生生成关联码并写入响应
+func respondLinkCode(c *gin.Context, studentID uint) {
+	code, err := defaultDrillHandler.redis.CreateParentLinkCode(c.Request.Context(), studentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成关联码失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":       code,
+		"expires_in": int(redis.ParentLinkCodeTTL.Seconds()),
+	})
+}
+
+// LinkChild 家长使用关联码关联学生
+func LinkChild(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供关联码"})
+		return
+	}
+
+	studentID, err := defaultDrillHandler.redis.ConsumeParentLinkCode(c.Request.Context(), strings.ToUpper(strings.TrimSpace(req.Code)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "关联码无效或已过期"})
+		return
+	}
+
+	var student model.User
+	if err := database.DB.Where("id = ? AND role = ?", studentID, model.RoleStudent).First(&student).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "关联码无效或已过期"})
+		return
+	}
+
+	link := model.ParentLink{ParentID: c.GetUint("user_id"), StudentID: student.ID}
+	if err := database.DB.Where(link).FirstOrCreate(&link).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "关联失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rosterStudent{
+		ID:        student.ID,
+		Username:  student.Username,
+		Name:      student.Name,
+		StudentNo: student.StudentNo,
+	})
+}
+
+// GetChildren 家长查看已关联的学生
+func GetChildren(c *gin.Context) {
+	var children []rosterStudent
+	if err := database.DB.Model(&model.User{}).
+		Select("users.id, users.username, users.name, users.student_no").
+		Joins("JOIN parent_links ON parent_links.student_id = users.id").
+		Where("parent_links.parent_id = ?", c.GetUint("user_id")).
+		Order("users.username").
+		Scan(&children).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取关联学生失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, children)
+}
+
+// GetChildHistory 家长查看孩子的历史记录
+func GetChildHistory(c *gin.Context) {
+	studentID, ok := loadChild(c)
+	if !ok {
+		return
+	}
+	respondHistory(c, studentID)
+}
+
+// GetChildStats 家长查看孩子的统计信息
+func GetChildStats(c *gin.Context) {
+	studentID, ok := loadChild(c)
+	if !ok {
+		return
+	}
+	respondStatistics(c, studentID)
+}
+
+// GetChildHomework 家长查看孩子的作业完成情况
+func GetChildHomework(c *gin.Context) {
+	studentID, ok := loadChild(c)
+	if !ok {
+		return
+	}
+	respondHomework(c, studentID)
+}
+
+// loadChild 校验路径参数中的学生已与当前家长关联，返回学生ID
+func loadChild(c *gin.Context) (uint, bool) {
+	studentID, ok := parseIDParam(c, "student_id")
+	if !ok {
+		return 0, false
+	}
+
+	var count int64
+	if err := database.DB.Model(&model.ParentLink{}).
+		Where("parent_id = ? AND student_id = ?", c.GetUint("user_id"), studentID).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取关联学生失败"})
+		return 0, false
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未关联该学生"})
+		return 0, false
+	}
+
+	return studentID, true
+}
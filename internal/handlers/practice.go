@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"calculator/internal/coin"
+	"calculator/internal/database"
+	"calculator/internal/drill"
+	"calculator/internal/model"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// 默认每次练习题数
+	defaultPracticeQuestionCount = 20
+	// 每次练习最大题数
+	maxPracticeQuestionCount = 100
+)
+
+// StartPractice 开始一次练习
+func StartPractice(c *gin.Context) {
+	var req struct {
+		Difficulty    string `json:"difficulty"`
+		QuestionCount int    `json:"question_count"`
+		TimeLimit     int    `json:"time_limit"` // 单题限时（秒），不填使用难度默认值
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if req.Difficulty == "" {
+		req.Difficulty = "easy"
+	}
+	difficulty, ok := drill.ParseDifficulty(req.Difficulty)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的难度"})
+		return
+	}
+
+	if req.QuestionCount == 0 {
+		req.QuestionCount = defaultPracticeQuestionCount
+	}
+	if req.QuestionCount < 0 || req.QuestionCount > maxPracticeQuestionCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("题数必须在1到%d之间", maxPracticeQuestionCount)})
+		return
+	}
+
+	if req.TimeLimit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的单题限时"})
+		return
+	}
+
+	session := model.PracticeSession{
+		UserID:        c.GetUint("user_id"),
+		Difficulty:    difficulty.String(),
+		QuestionCount: req.QuestionCount,
+		TimeLimit:     req.TimeLimit,
+		Status:        model.PracticeStatusInProgress,
+		StartedAt:     time.Now(),
+	}
+
+	if err := database.DB.Create(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建练习失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// GetPracticeSessions 获取用户的练习列表
+func GetPracticeSessions(c *gin.Context) {
+	var sessions []model.PracticeSession
+	if err := database.DB.Where("user_id = ?", c.GetUint("user_id")).
+		Order("started_at DESC").
+		Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取练习列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// GetPracticeSession 获取练习成绩单，包括错题
+func GetPracticeSession(c *gin.Context) {
+	session, ok := loadPracticeSession(c)
+	if !ok {
+		return
+	}
+
+	var wrongRecords []model.HistoryRecord
+	if err := database.DB.Where("session_id = ? AND is_correct = ?", session.ID, false).
+		Order("created_at ASC").
+		Find(&wrongRecords).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取错题失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session":       session,
+		"wrong_records": wrongRecords,
+	})
+}
+
+// GetPracticeQuestion 获取练习中的下一道题目
+func GetPracticeQuestion(c *gin.Context) {
+	session, ok := loadPracticeSession(c)
+	if !ok {
+		return
+	}
+
+	if session.Status != model.PracticeStatusInProgress {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "练习已结束"})
+		return
+	}
+
+	// 原子地占用一个题目名额，避免并发请求超发
+	result := database.DB.Model(&model.PracticeSession{}).
+		Where("id = ? AND status = ? AND issued < question_count", session.ID, model.PracticeStatusInProgress).
+		Update("issued", gorm.Expr("issued + 1"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取题目失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "本次练习的题目已全部发放"})
+		return
+	}
+
+	difficulty, _ := drill.ParseDifficulty(session.Difficulty)
+	questionID, question, err := issueQuestion(c.Request.Context(), difficulty, session.Templates, issuedQuestion{
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		TimeLimit: session.TimeLimit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存题目失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         questionID,
+		"question":   question.Expression,
+		"difficulty": session.Difficulty,
+		"time_limit": question.TimeLimit,
+		"index":      session.Issued + 1,
+		"total":      session.QuestionCount,
+	})
+}
+
+// SubmitPracticeAnswer 提交练习中的答案
+func SubmitPracticeAnswer(c *gin.Context) {
+	var req struct {
+		QuestionID int64 `json:"question_id" binding:"required"`
+		Answer     *int  `json:"answer" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	session, ok := loadPracticeSession(c)
+	if !ok {
+		return
+	}
+
+	if session.Status != model.PracticeStatusInProgress {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "练习已结束"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	question, err := loadQuestion(ctx, req.QuestionID)
+	if err != nil || question.SessionID != session.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "题目不存在或已过期"})
+		return
+	}
+
+	// 每道题只能作答一次
+	deleted, err := defaultDrillHandler.redis.Client.Del(ctx, questionKey(req.QuestionID)).Result()
+	if err != nil || deleted == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "题目已作答"})
+		return
+	}
+
+	record, err := recordAnswer(ctx, session.UserID, req.QuestionID, question, *req.Answer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存历史记录失败"})
+		return
+	}
+
+	// 成绩只按这里累加的计数计算，练习已结束时不再计入
+	updates := map[string]interface{}{"answered": gorm.Expr("answered + 1")}
+	if record.IsCorrect {
+		updates["score"] = gorm.Expr("score + 1")
+	}
+	result := database.DB.Model(&model.PracticeSession{}).
+		Where("id = ? AND status = ?", session.ID, model.PracticeStatusInProgress).
+		Updates(updates)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新练习进度失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "练习已结束"})
+		return
+	}
+
+	response := gin.H{
+		"correct":   record.IsCorrect,
+		"timed_out": record.TimedOut,
+		"message":   answerMessage(record),
+		"finished":  false,
+	}
+
+	// 最后一题作答后自动结束练习，重新读取累加后的作答数，并发提交最后几题时也能结束
+	var progress model.PracticeSession
+	if err := database.DB.Select("id", "answered").First(&progress, session.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新练习进度失败"})
+		return
+	}
+	if progress.Answered >= session.QuestionCount {
+		finished, err := finishPracticeSession(session.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "结束练习失败"})
+			return
+		}
+		response["finished"] = true
+		response["session"] = finished
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// FinishPractice 结束练习并生成成绩
+func FinishPractice(c *gin.Context) {
+	session, ok := loadPracticeSession(c)
+	if !ok {
+		return
+	}
+
+	if session.Status != model.PracticeStatusInProgress {
+		c.JSON(http.StatusOK, session)
+		return
+	}
+
+	// 作业需要把题目全部领完才能结束，避免只做一题就提交
+	if session.HomeworkID != nil && session.Issued < session.QuestionCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("作业共 %d 道题，还有 %d 道未完成", session.QuestionCount, session.QuestionCount-session.Issued)})
+		return
+	}
+
+	finished, err := finishPracticeSession(session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "结束练习失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, finished)
+}
+
+// finishPracticeSession 根据答题时累加的作答数和答对数计算成绩并将练习标记为结束
+// 锁定练习记录，结束后提交的答案不再计入
+func finishPracticeSession(sessionID uint) (*model.PracticeSession, error) {
+	var session model.PracticeSession
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, sessionID).Error; err != nil {
+			return err
+		}
+		if session.Status != model.PracticeStatusInProgress {
+			return nil
+		}
+
+		now := time.Now()
+		// 作业按布置的题数计算正确率，未作答（如超时未提交）的题目按错误计
+		total := session.Answered
+		if session.HomeworkID != nil {
+			total = session.QuestionCount
+		}
+		session.Accuracy = 0
+		if total > 0 {
+			session.Accuracy = float64(session.Score) / float64(total) * 100
+		}
+		session.Duration = now.Sub(session.StartedAt).Seconds()
+		session.Status = model.PracticeStatusFinished
+		session.FinishedAt = &now
+
+		// 作业在截止时间后完成时标记为迟交
+		if session.HomeworkID != nil {
+			var homework model.Homework
+			if err := tx.First(&homework, *session.HomeworkID).Error; err != nil {
+				return err
+			}
+			session.Late = now.After(homework.DueAt)
+
+			// 布置的题目全部由服务端发放且全部作答后发放金币，同一份作业只发放一次
+			// 作答数只在提交本次练习发放的题目时累加，不受客户端上报的历史记录影响
+			if session.Issued >= session.QuestionCount && session.Answered >= session.QuestionCount {
+				reference := fmt.Sprintf("homework:%d", homework.ID)
+				if _, err := coin.Post(tx, session.UserID, coin.HomeworkReward(session.Late), model.CoinReasonHomework, reference); err != nil && !errors.Is(err, coin.ErrDuplicate) {
+					return err
+				}
+			}
+		}
+
+		return tx.Save(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// loadPracticeSession 读取路径参数中的练习会话并校验归属，失败时直接写入响应
+func loadPracticeSession(c *gin.Context) (*model.PracticeSession, bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	var session model.PracticeSession
+	if err := database.DB.Where("id = ? AND user_id = ?", id, c.GetUint("user_id")).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "练习不存在"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取练习失败"})
+		}
+		return nil, false
+	}
+
+	return &session, true
+}
+
+// parseIDParam 解析路径中的数字ID参数，失败时直接写入响应
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"calculator/internal/redis"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProfile 获取当前用户的资料
+func GetProfile(c *gin.Context) {
+	var user model.User
+	if err := database.DB.First(&user, c.GetUint("user_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateProfile 修改当前用户的资料，只修改请求中提供的字段
+// 登录用户名不能修改；时区（IANA 名称，如 Asia/Shanghai）决定连续练习天数的“今天”
+func UpdateProfile(c *gin.Context) {
+	var req struct {
+		TimeZone *string `json:"time_zone"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.TimeZone == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供时区"})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	updates := make(map[string]interface{})
+
+	if req.TimeZone != nil {
+		timeZone := strings.TrimSpace(*req.TimeZone)
+		if _, err := redis.LoadTimeZone(timeZone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时区"})
+			return
+		}
+		updates["time_zone"] = timeZone
+	}
+
+	if err := database.DB.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改资料失败"})
+		return
+	}
+
+	// 清除缓存失败不影响修改结果，时区缓存过期后会自动更新
+	ctx := c.Request.Context()
+	if req.TimeZone != nil {
+		if err := defaultDrillHandler.redis.InvalidateTimeZone(ctx, userID); err != nil {
+			fmt.Printf("更新时区缓存失败: %v\n", err)
+		}
+	}
+
+	GetProfile(c)
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"calculator/internal/redis"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// 排行榜默认每页人数
+	defaultRankingPageSize = 10
+	// 排行榜每页最多人数
+	maxRankingPageSize = 100
+	// 我的排名默认显示前后各几名
+	defaultRankRadius = 5
+	// 我的排名最多显示前后各几名
+	maxRankRadius = 50
+	// 排名趋势默认天数
+	defaultRankHistoryDays = 30
+	// 排名趋势最多天数
+	maxRankHistoryDays = 365
+)
+
+// parseRankScope 解析排行榜范围参数（scope=class&class_id= 或 scope=school&school_id=）
+// 并校验当前用户是否可以查看，scope 为空时为全站排行榜。失败时直接写入响应
+func parseRankScope(c *gin.Context) (redis.RankScope, bool) {
+	scope := c.DefaultQuery("scope", redis.RankScopeGlobal)
+
+	var idParam string
+	switch scope {
+	case redis.RankScopeGlobal:
+		return redis.GlobalRankScope, true
+	case redis.RankScopeClass:
+		idParam = "class_id"
+	case redis.RankScopeSchool:
+		idParam = "school_id"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的排行榜范围"})
+		return redis.RankScope{}, false
+	}
+
+	id, err := strconv.ParseUint(c.Query(idParam), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供有效的" + idParam})
+		return redis.RankScope{}, false
+	}
+
+	rankScope := redis.RankScope{Scope: scope, ID: uint(id)}
+	allowed, err := canViewRankScope(c.GetUint("user_id"), c.GetString("role"), rankScope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取排行榜失败"})
+		return redis.RankScope{}, false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权查看该排行榜"})
+		return redis.RankScope{}, false
+	}
+
+	return rankScope, true
+}
+
+// canViewRankScope 判断用户能否查看班级或学校排行榜：
+// 学生须在该班级（学校）中，教师须任教该班级（学校的某个班级），家长须有孩子在其中，管理员不受限制
+func canViewRankScope(userID uint, role string, scope redis.RankScope) (bool, error) {
+	if role == model.RoleAdmin {
+		return true, nil
+	}
+
+	classFilter := "classes.id = ?"
+	if scope.Scope == redis.RankScopeSchool {
+		classFilter = "classes.school_id = ?"
+	}
+
+	var query *gorm.DB
+	switch role {
+	case model.RoleTeacher:
+		query = database.DB.Model(&model.Class{}).
+			Where("classes.teacher_id = ?", userID)
+	case model.RoleStudent:
+		query = database.DB.Model(&model.Class{}).
+			Joins("JOIN enrollments ON enrollments.class_id = classes.id").
+			Where("enrollments.student_id = ?", userID)
+	case model.RoleParent:
+		query = database.DB.Model(&model.Class{}).
+			Joins("JOIN enrollments ON enrollments.class_id = classes.id").
+			Joins("JOIN parent_links ON parent_links.student_id = enrollments.student_id").
+			Where("parent_links.parent_id = ?", userID)
+	default:
+		return false, nil
+	}
+
+	var count int64
+	if err := query.Where(classFilter, scope.ID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// parsePage 解析分页参数 page（从1开始）和 page_size，失败时直接写入响应
+func parsePage(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的页码"})
+		return 0, 0, false
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultRankingPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxRankingPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("每页人数必须在1到%d之间", maxRankingPageSize)})
+		return 0, 0, false
+	}
+
+	return page, pageSize, true
+}
+
+// GetMyRank 获取当前用户在排行榜中的名次、分数、与前一名的分差以及前后 radius 名用户
+func GetMyRank(c *gin.Context) {
+	rankType := c.DefaultQuery("type", redis.PeriodHourly)
+	if !redis.ValidRankPeriod(rankType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的排行榜类型"})
+		return
+	}
+
+	scope, ok := parseRankScope(c)
+	if !ok {
+		return
+	}
+
+	radius, err := strconv.Atoi(c.DefaultQuery("radius", strconv.Itoa(defaultRankRadius)))
+	if err != nil || radius < 0 || radius > maxRankRadius {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("radius 必须在0到%d之间", maxRankRadius)})
+		return
+	}
+
+	rank, err := defaultDrillHandler.redis.GetUserRank(c.Request.Context(), scope, rankType, c.GetUint("user_id"), int64(radius))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取排名失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, rank)
+}
+
+// GetMyRankHistory 获取当前用户最近若干天的排名变化，用于绘制趋势图
+// 排名每天存档一次，type 只支持热度榜（hot）和总榜（all）
+func GetMyRankHistory(c *gin.Context) {
+	rankType := c.DefaultQuery("type", redis.PeriodHot)
+	supported := false
+	for _, period := range redis.SnapshotPeriods {
+		if period == rankType {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该排行榜不支持查看趋势"})
+		return
+	}
+
+	scope, ok := parseRankScope(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultRankHistoryDays)))
+	if err != nil || days < 1 || days > maxRankHistoryDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("天数必须在1到%d之间", maxRankHistoryDays)})
+		return
+	}
+
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, now.Location())
+
+	var points []struct {
+		Date  time.Time `json:"date"`
+		Rank  int       `json:"rank"`
+		Score float64   `json:"score"`
+	}
+	if err := database.DB.Model(&model.RankSnapshot{}).
+		Select("date, `rank`, score").
+		Where("user_id = ? AND period = ? AND scope = ? AND scope_id = ? AND date >= ?",
+			c.GetUint("user_id"), rankType, scope.Scope, scope.ID, since).
+		Order("date").
+		Scan(&points).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取排名趋势失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":   rankType,
+		"since":  since,
+		"points": points,
+	})
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"calculator/internal/middleware"
+	"calculator/internal/redis"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// rankingStreamInterval 合并排行榜变化并推送的间隔，同一排行榜在间隔内多次变化只推送一次
+	rankingStreamInterval = time.Second
+	// rankingStreamHeartbeat 心跳间隔，避免代理断开空闲连接
+	rankingStreamHeartbeat = 30 * time.Second
+	// rankingStreamBuffer 每个客户端最多缓存的推送数，缓存满时丢弃最旧的一条
+	rankingStreamBuffer = 4
+	// rankingStreamSize 推送的排行榜人数
+	rankingStreamSize = 10
+)
+
+// rankingBoard 客户端订阅的排行榜
+type rankingBoard struct {
+	scope  redis.RankScope
+	period string
+}
+
+// rankingSubscriber 客户端接收排行榜推送的 channel
+type rankingSubscriber chan []redis.RankingItem
+
+// rankingHub 管理本实例的排行榜订阅：通过 Redis 发布订阅接收所有实例的分数变化，
+// 定期重新读取发生变化的排行榜并推送给订阅的客户端
+type rankingHub struct {
+	once   sync.Once
+	mu     sync.Mutex
+	boards map[rankingBoard]map[rankingSubscriber]struct{}
+	dirty  map[redis.RankScope]bool
+}
+
+var defaultRankingHub = &rankingHub{
+	boards: make(map[rankingBoard]map[rankingSubscriber]struct{}),
+	dirty:  make(map[redis.RankScope]bool),
+}
+
+// subscribe 订阅排行榜推送，首次订阅时启动后台推送
+func (h *rankingHub) subscribe(board rankingBoard) rankingSubscriber {
+	h.once.Do(func() {
+		go h.run(context.Background())
+	})
+
+	sub := make(rankingSubscriber, rankingStreamBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.boards[board] == nil {
+		h.boards[board] = make(map[rankingSubscriber]struct{})
+	}
+	h.boards[board][sub] = struct{}{}
+	return sub
+}
+
+// unsubscribe 取消订阅
+func (h *rankingHub) unsubscribe(board rankingBoard, sub rankingSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.boards[board], sub)
+	if len(h.boards[board]) == 0 {
+		delete(h.boards, board)
+	}
+}
+
+// run 接收分数变化通知并定期推送
+func (h *rankingHub) run(ctx context.Context) {
+	ticker := time.NewTicker(rankingStreamInterval)
+	defer ticker.Stop()
+
+	updates := defaultDrillHandler.redis.SubscribeRankUpdates(ctx)
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				// 订阅断开后重新订阅
+				time.Sleep(rankingStreamInterval)
+				updates = defaultDrillHandler.redis.SubscribeRankUpdates(ctx)
+				continue
+			}
+			h.mu.Lock()
+			for _, scope := range update.Scopes {
+				h.dirty[scope] = true
+			}
+			h.mu.Unlock()
+		case <-ticker.C:
+			h.flush(ctx)
+		}
+	}
+}
+
+// flush 重新读取发生变化且有人订阅的排行榜，推送给订阅者
+func (h *rankingHub) flush(ctx context.Context) {
+	h.mu.Lock()
+	changed := make(map[rankingBoard][]rankingSubscriber)
+	for board, subs := range h.boards {
+		if !h.dirty[board.scope] {
+			continue
+		}
+		for sub := range subs {
+			changed[board] = append(changed[board], sub)
+		}
+	}
+	h.dirty = make(map[redis.RankScope]bool)
+	h.mu.Unlock()
+
+	for board, subs := range changed {
+		rankings, _, err := defaultDrillHandler.redis.GetHotRanking(ctx, board.scope, board.period, 0, rankingStreamSize)
+		if err != nil {
+			fmt.Printf("推送排行榜失败: %v\n", err)
+			continue
+		}
+		for _, sub := range subs {
+			deliverRankings(sub, rankings)
+		}
+	}
+}
+
+// deliverRankings 非阻塞地推送给客户端，缓存已满时丢弃最旧的一条，慢客户端不会阻塞其他客户端
+// 每条推送都是完整的排行榜，丢弃旧数据不影响客户端显示最新结果
+func deliverRankings(sub rankingSubscriber, rankings []redis.RankingItem) {
+	for {
+		select {
+		case sub <- rankings:
+			return
+		default:
+		}
+		select {
+		case <-sub:
+		default:
+		}
+	}
+}
+
+// streamTicketBytes 实时推送票据的随机字节数
+const streamTicketBytes = 24
+
+// CreateStreamTicket 为当前用户签发实时推送的一次性票据，EventSource 无法设置请求头，
+// 通过 ticket 参数传递票据而不是访问令牌
+func CreateStreamTicket(c *gin.Context) {
+	id, err := randomToken(streamTicketBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成票据失败"})
+		return
+	}
+
+	ticket := redis.StreamTicket{
+		UserID:    c.GetUint("user_id"),
+		Username:  c.GetString("username"),
+		Role:      c.GetString("role"),
+		SessionID: c.GetString("session_id"),
+	}
+	if err := defaultDrillHandler.redis.SaveStreamTicket(c.Request.Context(), id, ticket); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成票据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ticket":     id,
+		"expires_in": int(redis.StreamTicketTTL.Seconds()),
+	})
+}
+
+// StreamAuth 实时推送的认证：有 ticket 参数时使用一次性票据认证，否则按 Authorization 请求头认证
+func StreamAuth() gin.HandlerFunc {
+	authRequired := middleware.AuthRequired()
+	return func(c *gin.Context) {
+		id := c.Query("ticket")
+		if id == "" {
+			authRequired(c)
+			return
+		}
+
+		ticket, err := defaultDrillHandler.redis.ConsumeStreamTicket(c.Request.Context(), id)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "票据无效或已过期"})
+			return
+		}
+
+		c.Set("user_id", ticket.UserID)
+		c.Set("username", ticket.Username)
+		c.Set("role", ticket.Role)
+		c.Set("session_id", ticket.SessionID)
+		c.Next()
+	}
+}
+
+// sessionEnded 判断推送连接所属的会话是否已吊销，检查失败时不断开
+func sessionEnded(ctx context.Context, sid string) bool {
+	if sid == "" {
+		return false
+	}
+	revoked, err := SessionRevoked(ctx, sid)
+	if err != nil {
+		fmt.Printf("检查会话状态失败: %v\n", err)
+		return false
+	}
+	return revoked
+}
+
+// StreamRankings 通过 Server-Sent Events 推送排行榜变化，参数与 GetHotRanking 相同
+// 连接建立后先推送一次当前排行榜，之后排行榜发生变化时推送 rankings 事件
+// 每次心跳时检查会话，会话已登出或被吊销时断开连接，客户端重连时需要重新取得票据
+func StreamRankings(c *gin.Context) {
+	rankType := c.DefaultQuery("type", redis.PeriodHourly)
+	if !redis.ValidRankPeriod(rankType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的排行榜类型"})
+		return
+	}
+
+	scope, ok := parseRankScope(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	sid := c.GetString("session_id")
+	rankings, _, err := defaultDrillHandler.redis.GetHotRanking(ctx, scope, rankType, 0, rankingStreamSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取排行榜失败: %v", err)})
+		return
+	}
+
+	board := rankingBoard{scope: scope, period: rankType}
+	sub := defaultRankingHub.subscribe(board)
+	defer defaultRankingHub.unsubscribe(board, sub)
+	deliverRankings(sub, rankings)
+
+	heartbeat := time.NewTicker(rankingStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case rankings := <-sub:
+			c.SSEvent("rankings", gin.H{"rankings": rankings})
+			return true
+		case <-heartbeat.C:
+			if sessionEnded(ctx, sid) {
+				return false
+			}
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errSeasonOverlap 赛季时间与已有的自定义赛季重叠
+var errSeasonOverlap = errors.New("season overlap")
+
+// GetSeasons 获取赛季列表，最新的在前
+func GetSeasons(c *gin.Context) {
+	var seasons []model.Season
+	if err := database.DB.Order("starts_at DESC").Find(&seasons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取赛季列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, seasons)
+}
+
+// GetSeasonStandings 查看已结束赛季的最终排名，范围参数与排行榜相同（scope=class&class_id= 等）
+func GetSeasonStandings(c *gin.Context) {
+	seasonID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var season model.Season
+	if err := database.DB.First(&season, seasonID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "赛季不存在"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取赛季失败"})
+		}
+		return
+	}
+	if season.Status != model.SeasonStatusArchived {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "赛季尚未结束，请查看当前赛季排行榜"})
+		return
+	}
+
+	scope, ok := parseRankScope(c)
+	if !ok {
+		return
+	}
+
+	var standings []model.SeasonStanding
+	if err := database.DB.Where("season_id = ? AND scope = ? AND scope_id = ?", season.ID, scope.Scope, scope.ID).
+		Order("`rank`").Find(&standings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取赛季排名失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"season":    season,
+		"standings": standings,
+	})
+}
+
+// CreateSeason 管理员创建自定义赛季（如学期），不能与其他自定义赛季重叠
+// 与之重叠的自动月度赛季会提前到新赛季开始时结束
+func CreateSeason(c *gin.Context) {
+	var req struct {
+		Name     string    `json:"name" binding:"required"`
+		StartsAt time.Time `json:"starts_at" binding:"required"`
+		EndsAt   time.Time `json:"ends_at" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供完整的赛季信息"})
+		return
+	}
+
+	if req.StartsAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "赛季开始时间不能早于当前时间"})
+		return
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "赛季结束时间必须晚于开始时间"})
+		return
+	}
+
+	season := model.Season{
+		Name:     req.Name,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Status:   model.SeasonStatusActive,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Season{}).
+			Where("auto = ? AND starts_at < ? AND ends_at > ?", false, season.EndsAt, season.StartsAt).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errSeasonOverlap
+		}
+
+		// 自动赛季只会覆盖当前时间，因此与新赛季重叠时一定开始得更早，将其截止到新赛季开始
+		if err := tx.Model(&model.Season{}).
+			Where("auto = ? AND starts_at < ? AND ends_at > ?", true, season.EndsAt, season.StartsAt).
+			Update("ends_at", season.StartsAt).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&season).Error
+	})
+	if errors.Is(err, errSeasonOverlap) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "赛季时间与已有赛季重叠"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建赛季失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, season)
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/jwtkeys"
+	"calculator/internal/model"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// 访问令牌默认有效期，可通过环境变量 ACCESS_TOKEN_TTL 配置
+	defaultAccessTokenTTL = 15 * time.Minute
+	// 刷新令牌默认有效期，可通过环境变量 REFRESH_TOKEN_TTL 配置，每次刷新重新计算
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	// 刷新令牌随机字节数
+	refreshTokenBytes = 32
+	// 刷新令牌使用后的宽限时间，宽限时间内再次使用不视为泄露
+	refreshReuseGrace = 10 * time.Second
+)
+
+var (
+	// errRefreshTokenInvalid 刷新令牌不存在、已过期或已吊销
+	errRefreshTokenInvalid = errors.New("invalid refresh token")
+	// errRefreshTokenReused 已使用过的刷新令牌被再次使用
+	errRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// durationEnv 读取时长配置（如 15m、720h），未设置或无效时使用默认值
+func durationEnv(key string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return defaultValue
+	}
+	return d
+}
+
+// randomToken 生成 n 字节的随机令牌（base64url 编码）
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机令牌失败: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashRefreshToken 数据库中只保存刷新令牌的哈希
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// signAccessToken 为用户签发访问令牌，sid 为所属的会话 FamilyID
+func signAccessToken(user *model.User, familyID string, expiresAt time.Time) (string, error) {
+	return jwtkeys.Sign(jwt.MapClaims{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+		"sid":      familyID,
+		"exp":      expiresAt.Unix(),
+	})
+}
+
+// GetJWKS 返回验证访问令牌所需的公钥（JWKS），供其他服务验证 token，HS256 密钥不会公开
+func GetJWKS(c *gin.Context) {
+	keys, err := jwtkeys.Default()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "签名密钥未配置"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": keys.JWKS()})
+}
+
+// createSession 在事务中为用户保存一个新的刷新令牌，返回令牌明文
+func createSession(tx *gorm.DB, userID uint, familyID string) (string, error) {
+	refreshToken, err := randomToken(refreshTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	session := model.Session{
+		UserID:    userID,
+		Token:     hashRefreshToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(durationEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)),
+	}
+	if err := tx.Create(&session).Error; err != nil {
+		return "", fmt.Errorf("保存会话失败: %v", err)
+	}
+	return refreshToken, nil
+}
+
+// respondTokens 签发访问令牌并返回登录信息
+func respondTokens(c *gin.Context, user *model.User, familyID, refreshToken string) {
+	ttl := durationEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+	accessToken, err := signAccessToken(user, familyID, time.Now().Add(ttl))
+	if err != nil {
+		log.Printf("生成token失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         accessToken,
+		"expires_in":    int(ttl.Seconds()),
+		"refresh_token": refreshToken,
+		"username":      user.Username,
+		"role":          user.Role,
+	})
+}
+
+// startSession 登录成功后创建新的会话并返回令牌
+func startSession(c *gin.Context, user *model.User) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建会话失败"})
+		return
+	}
+
+	refreshToken, err := createSession(database.DB, user.ID, familyID)
+	if err != nil {
+		log.Printf("创建会话失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建会话失败"})
+		return
+	}
+
+	// 顺便清理该用户已过期的会话
+	if err := database.DB.Unscoped().Where("user_id = ? AND expires_at < ?", user.ID, time.Now()).
+		Delete(&model.Session{}).Error; err != nil {
+		log.Printf("清理过期会话失败: %v", err)
+	}
+
+	respondTokens(c, user, familyID, refreshToken)
+}
+
+// rotateSession 使用刷新令牌换取新的刷新令牌，旧令牌标记为已使用
+// 已使用过的令牌超过宽限时间后再次出现说明令牌可能泄露，吊销整个会话
+func rotateSession(ctx context.Context, refreshToken string) (*model.User, string, string, error) {
+	var session model.Session
+	if err := database.DB.Where("token = ?", hashRefreshToken(refreshToken)).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", "", errRefreshTokenInvalid
+		}
+		return nil, "", "", err
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, "", "", errRefreshTokenInvalid
+	}
+
+	var user model.User
+	var newToken string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// 锁定令牌记录，并发刷新时依次判断
+		var current model.Session
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, session.ID).Error; err != nil {
+			return err
+		}
+		if current.RevokedAt != nil {
+			return errRefreshTokenInvalid
+		}
+		if current.UsedAt == nil {
+			if err := tx.Model(&current).Update("used_at", time.Now()).Error; err != nil {
+				return err
+			}
+		} else if time.Since(*current.UsedAt) > refreshReuseGrace {
+			return errRefreshTokenReused
+		}
+		// 宽限时间内再次使用（如多个标签页同时刷新）时为同一会话签发另一个刷新令牌，
+		// 已签发的后继令牌只保存了哈希，无法再次返回
+
+		if err := tx.First(&user, session.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errRefreshTokenInvalid
+			}
+			return err
+		}
+
+		var err error
+		newToken, err = createSession(tx, user.ID, session.FamilyID)
+		return err
+	})
+	if errors.Is(err, errRefreshTokenReused) {
+		if revokeErr := revokeSessionFamily(ctx, session.FamilyID); revokeErr != nil {
+			log.Printf("吊销会话失败: %v", revokeErr)
+		}
+		log.Printf("检测到刷新令牌重复使用，已吊销会话: user_id=%d", session.UserID)
+	}
+	if err != nil {
+		return nil, "", "", err
+	}
+	return &user, session.FamilyID, newToken, nil
+}
+
+// revokeSessionFamily 吊销同一次登录产生的所有刷新令牌，并在 Redis 中记录，使已签发的访问令牌随即失效
+func revokeSessionFamily(ctx context.Context, familyID string) error {
+	if err := database.DB.Model(&model.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	return defaultDrillHandler.redis.MarkSessionRevoked(ctx, familyID, durationEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL))
+}
+
+// SessionRevoked 判断访问令牌所属的会话是否已吊销：先查 Redis，Redis 不可用时查询 MySQL
+func SessionRevoked(ctx context.Context, familyID string) (bool, error) {
+	revoked, err := defaultDrillHandler.redis.SessionRevoked(ctx, familyID)
+	if err == nil {
+		return revoked, nil
+	}
+	log.Printf("%v，改为查询数据库", err)
+
+	var count int64
+	if err := database.DB.Model(&model.Session{}).
+		Where("family_id = ? AND revoked_at IS NOT NULL", familyID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌和刷新令牌，旧的刷新令牌随即失效
+func RefreshToken(c *gin.Context) {
+	var input struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供刷新令牌"})
+		return
+	}
+
+	user, familyID, refreshToken, err := rotateSession(c.Request.Context(), input.RefreshToken)
+	switch {
+	case errors.Is(err, errRefreshTokenReused):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "刷新令牌已被使用，请重新登录"})
+		return
+	case errors.Is(err, errRefreshTokenInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "刷新令牌无效或已过期，请重新登录"})
+		return
+	case err != nil:
+		log.Printf("刷新令牌失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "刷新令牌失败"})
+		return
+	}
+
+	respondTokens(c, user, familyID, refreshToken)
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/drill"
+	"calculator/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// sprintTimeLimit 限时冲刺的时长
+	sprintTimeLimit = 60 * time.Second
+	// sprintFinalizeInterval 结算已过截止时间的冲刺的间隔
+	sprintFinalizeInterval = 30 * time.Second
+)
+
+// StartSprint 开始一次限时冲刺，返回第一道题
+func StartSprint(c *gin.Context) {
+	var req struct {
+		Difficulty string `json:"difficulty"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if req.Difficulty == "" {
+		req.Difficulty = "easy"
+	}
+	difficulty, ok := drill.ParseDifficulty(req.Difficulty)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的难度"})
+		return
+	}
+
+	now := time.Now()
+	sprint := model.SprintResult{
+		UserID:     c.GetUint("user_id"),
+		Difficulty: difficulty.String(),
+		TimeLimit:  int(sprintTimeLimit.Seconds()),
+		Status:     model.SprintStatusInProgress,
+		StartedAt:  now,
+		EndsAt:     now.Add(sprintTimeLimit),
+	}
+
+	if err := database.DB.Create(&sprint).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建冲刺失败"})
+		return
+	}
+
+	next, err := issueSprintQuestion(c.Request.Context(), &sprint)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存题目失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sprint":       sprint,
+		"question":     next,
+		"remaining_ms": time.Until(sprint.EndsAt).Milliseconds(),
+	})
+}
+
+// SubmitSprintAnswer 提交冲刺中的答案，并立即返回下一道题
+func SubmitSprintAnswer(c *gin.Context) {
+	var req struct {
+		QuestionID int64 `json:"question_id" binding:"required"`
+		Answer     *int  `json:"answer" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	sprint, ok := loadSprint(c)
+	if !ok {
+		return
+	}
+
+	if sprint.Status != model.SprintStatusInProgress {
+		c.JSON(http.StatusConflict, gin.H{"error": "冲刺已结束", "sprint": sprint})
+		return
+	}
+
+	// 超过截止时间的答案一律拒绝，并结算成绩
+	if !time.Now().Before(sprint.EndsAt) {
+		finished, err := finishSprint(c.Request.Context(), sprint.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "结算冲刺失败"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "冲刺时间已到", "sprint": finished})
+		return
+	}
+
+	ctx := c.Request.Context()
+	question, err := loadQuestion(ctx, req.QuestionID)
+	if err != nil || question.SprintID != sprint.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "题目不存在或已过期"})
+		return
+	}
+
+	// 每道题只能作答一次
+	deleted, err := defaultDrillHandler.redis.Client.Del(ctx, questionKey(req.QuestionID)).Result()
+	if err != nil || deleted == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "题目已作答"})
+		return
+	}
+
+	record, err := recordAnswer(ctx, sprint.UserID, req.QuestionID, question, *req.Answer)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存历史记录失败"})
+		return
+	}
+
+	updates := map[string]interface{}{"answered": gorm.Expr("answered + 1")}
+	if record.IsCorrect {
+		updates["correct"] = gorm.Expr("correct + 1")
+	}
+	if err := database.DB.Model(&model.SprintResult{}).Where("id = ?", sprint.ID).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新冲刺进度失败"})
+		return
+	}
+
+	next, err := issueSprintQuestion(ctx, sprint)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存题目失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"correct":      record.IsCorrect,
+		"timed_out":    record.TimedOut,
+		"message":      answerMessage(record),
+		"question":     next,
+		"remaining_ms": time.Until(sprint.EndsAt).Milliseconds(),
+	})
+}
+
+// GetSprint 获取冲刺成绩，截止时间已过的冲刺会被自动结算
+func GetSprint(c *gin.Context) {
+	sprint, ok := loadSprint(c)
+	if !ok {
+		return
+	}
+
+	if sprint.Status == model.SprintStatusInProgress && !time.Now().Before(sprint.EndsAt) {
+		finished, err := finishSprint(c.Request.Context(), sprint.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "结算冲刺失败"})
+			return
+		}
+		sprint = finished
+	}
+
+	c.JSON(http.StatusOK, sprint)
+}
+
+// GetSprintRanking 获取冲刺排行榜（个人最好成绩）
+func GetSprintRanking(c *gin.Context) {
+	difficulty, ok := drill.ParseDifficulty(c.DefaultQuery("difficulty", "easy"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的难度"})
+		return
+	}
+
+	rankings, err := defaultDrillHandler.redis.GetSprintRanking(c.Request.Context(), difficulty.String(), 10)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取排行榜失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rankings": rankings,
+	})
+}
+
+// issueSprintQuestion 为冲刺发放下一道题目
+func issueSprintQuestion(ctx context.Context, sprint *model.SprintResult) (gin.H, error) {
+	difficulty, _ := drill.ParseDifficulty(sprint.Difficulty)
+	questionID, question, err := issueQuestion(ctx, difficulty, nil, issuedQuestion{
+		UserID:   sprint.UserID,
+		SprintID: sprint.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"id":         questionID,
+		"question":   question.Expression,
+		"difficulty": sprint.Difficulty,
+	}, nil
+}
+
+// finishSprint 结算冲刺成绩并更新冲刺排行榜，重复调用不会重复结算
+func finishSprint(ctx context.Context, sprintID uint) (*model.SprintResult, error) {
+	now := time.Now()
+	result := database.DB.Model(&model.SprintResult{}).
+		Where("id = ? AND status = ?", sprintID, model.SprintStatusInProgress).
+		Updates(map[string]interface{}{
+			"status":      model.SprintStatusFinished,
+			"finished_at": now,
+			"accuracy":    gorm.Expr("CASE WHEN answered > 0 THEN correct * 100.0 / answered ELSE 0 END"),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var sprint model.SprintResult
+	if err := database.DB.First(&sprint, sprintID).Error; err != nil {
+		return nil, err
+	}
+
+	// 只有本次调用完成结算时才更新排行榜
+	if result.RowsAffected > 0 {
+		if err := defaultDrillHandler.redis.UpdateSprintBest(ctx, sprint.Difficulty, sprint.UserID, float64(sprint.Correct)); err != nil {
+			fmt.Printf("更新冲刺排行榜失败: %v\n", err)
+		}
+	}
+
+	return &sprint, nil
+}
+
+// StartSprintFinalizer 定期结算已过截止时间但客户端未再提交或查询的冲刺，使中途离开的冲刺也能进入排行榜
+func StartSprintFinalizer() {
+	go func() {
+		ticker := time.NewTicker(sprintFinalizeInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := finishExpiredSprints(context.Background()); err != nil {
+				fmt.Printf("结算过期冲刺失败: %v\n", err)
+			}
+		}
+	}()
+}
+
+// finishExpiredSprints 结算所有已过截止时间的冲刺，多个实例同时结算时每个冲刺只会结算一次
+func finishExpiredSprints(ctx context.Context) error {
+	var ids []uint
+	if err := database.DB.Model(&model.SprintResult{}).
+		Where("status = ? AND ends_at <= ?", model.SprintStatusInProgress, time.Now()).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := finishSprint(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadSprint 读取路径参数中的冲刺并校验归属，失败时直接写入响应
+func loadSprint(c *gin.Context) (*model.SprintResult, bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	var sprint model.SprintResult
+	if err := database.DB.Where("id = ? AND user_id = ?", id, c.GetUint("user_id")).First(&sprint).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "冲刺不存在"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取冲刺失败"})
+		}
+		return nil, false
+	}
+
+	return &sprint, true
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// 日历热力图默认天数
+	defaultCalendarDays = 365
+	// 日历热力图最多天数
+	maxCalendarDays = 730
+)
+
+// GetStreak 获取当前用户的连续练习天数，“今天”按用户设置的时区计算
+func GetStreak(c *gin.Context) {
+	streak, err := defaultDrillHandler.redis.GetStreak(c.Request.Context(), c.GetUint("user_id"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取连续练习天数失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, streak)
+}
+
+// GetActivityCalendar 获取当前用户最近 days 天每天的答题数，用于日历热力图
+func GetActivityCalendar(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultCalendarDays)))
+	if err != nil || days < 1 || days > maxCalendarDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("天数必须在1到%d之间", maxCalendarDays)})
+		return
+	}
+
+	calendar, err := defaultDrillHandler.redis.GetActivityCalendar(c.Request.Context(), c.GetUint("user_id"), time.Now(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取练习日历失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"days": calendar})
+}
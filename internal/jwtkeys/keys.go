@@ -0,0 +1,345 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+const (
+	// LegacyKeyID 只配置 JWT_SECRET 时密钥的 kid
+	LegacyKeyID = "default"
+	// minSecretLength HS256 密钥的最小长度（字节）
+	minSecretLength = 32
+)
+
+var (
+	// ErrUnknownKey token 的 kid 不在当前配置的密钥中
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrAlgMismatch token 声明的算法与 kid 对应密钥的算法不一致
+	ErrAlgMismatch = errors.New("signing algorithm does not match key")
+)
+
+// Key 一把签名密钥，没有私钥（或 HS256 密钥）时只用于验证
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeySet 当前配置的所有密钥：active 用于签发新 token，其余密钥只用于验证尚未过期的旧 token，
+// 轮换时先加入新密钥并切换 active，待旧 token 全部过期后再移除旧密钥
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// Config 密钥配置文件（JWT_KEYS_FILE）的格式
+type Config struct {
+	Active string      `json:"active"` // 签发新 token 使用的 kid
+	Keys   []KeyConfig `json:"keys"`
+}
+
+// KeyConfig 单个密钥的配置，PEM 文件的相对路径相对于配置文件所在目录
+type KeyConfig struct {
+	ID             string `json:"kid"`
+	Alg            string `json:"alg"`              // HS256、RS256 或 EdDSA
+	Secret         string `json:"secret"`           // HS256 密钥
+	PrivateKeyFile string `json:"private_key_file"` // RS256/EdDSA 私钥（PKCS#1/PKCS#8 PEM），用于签发和验证
+	PublicKeyFile  string `json:"public_key_file"`  // RS256/EdDSA 公钥（PEM），未配置私钥时只用于验证
+}
+
+// NewKeySet 根据配置创建密钥集合，baseDir 为 PEM 文件相对路径的基准目录
+func NewKeySet(config Config, baseDir string) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key)}
+	for _, kc := range config.Keys {
+		if kc.ID == "" {
+			return nil, fmt.Errorf("密钥缺少 kid")
+		}
+		if _, ok := ks.keys[kc.ID]; ok {
+			return nil, fmt.Errorf("密钥 kid 重复: %s", kc.ID)
+		}
+		key, err := loadKey(kc, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("加载密钥 %s 失败: %v", kc.ID, err)
+		}
+		ks.keys[kc.ID] = key
+	}
+
+	active, ok := ks.keys[config.Active]
+	if !ok {
+		return nil, fmt.Errorf("active 密钥 %q 不存在", config.Active)
+	}
+	if active.signKey == nil {
+		return nil, fmt.Errorf("active 密钥 %s 没有私钥，无法签发 token", active.ID)
+	}
+	ks.active = active
+	return ks, nil
+}
+
+// loadKey 按算法加载密钥
+func loadKey(kc KeyConfig, baseDir string) (*Key, error) {
+	key := &Key{ID: kc.ID}
+
+	readPEM := func(path string) ([]byte, error) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		return os.ReadFile(path)
+	}
+
+	switch kc.Alg {
+	case AlgHS256:
+		if len(kc.Secret) < minSecretLength {
+			return nil, fmt.Errorf("HS256 密钥至少需要 %d 个字符", minSecretLength)
+		}
+		return hmacKey(kc.ID, kc.Secret), nil
+
+	case AlgRS256:
+		key.Method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			data, err := readPEM(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = private
+			key.verifyKey = &private.PublicKey
+		} else if kc.PublicKeyFile != "" {
+			data, err := readPEM(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = public
+		}
+
+	case AlgEdDSA:
+		key.Method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			data, err := readPEM(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			parsed, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			private, ok := parsed.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("不是 Ed25519 私钥")
+			}
+			key.signKey = private
+			key.verifyKey = private.Public()
+		} else if kc.PublicKeyFile != "" {
+			data, err := readPEM(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			parsed, err := jwt.ParseEdPublicKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			public, ok := parsed.(ed25519.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("不是 Ed25519 公钥")
+			}
+			key.verifyKey = public
+		}
+
+	default:
+		return nil, fmt.Errorf("不支持的算法 %q", kc.Alg)
+	}
+
+	if key.verifyKey == nil {
+		return nil, fmt.Errorf("%s 密钥需要配置 private_key_file 或 public_key_file", kc.Alg)
+	}
+	return key, nil
+}
+
+// hmacKey 创建 HS256 密钥，同一个密钥用于签发和验证
+func hmacKey(id, secret string) *Key {
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, signKey: []byte(secret), verifyKey: []byte(secret)}
+}
+
+// Sign 使用 active 密钥签发 token，header 中带有 kid
+func (ks *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.signKey)
+}
+
+// Parse 验证 token 并返回 claims：token 必须带有已配置的 kid，且算法与该密钥的算法完全一致，
+// 避免用公钥当作 HS256 密钥伪造签名等算法混淆攻击
+func (ks *KeySet) Parse(tokenString string) (jwt.MapClaims, error) {
+	parser := jwt.Parser{ValidMethods: ks.algorithms()}
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, ErrAlgMismatch
+		}
+		return key.verifyKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		return claims, nil
+	}
+	return nil, jwt.ErrSignatureInvalid
+}
+
+// algorithms 返回已配置密钥使用的算法
+func (ks *KeySet) algorithms() []string {
+	seen := make(map[string]bool)
+	var algs []string
+	for _, key := range ks.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// JWK JSON Web Key（RFC 7517）中的公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS 返回所有非对称密钥的公钥，HS256 密钥不公开，按 kid 排序
+func (ks *KeySet) JWKS() []JWK {
+	jwks := []JWK{}
+	for _, key := range ks.keys {
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: AlgRS256,
+				N:   jwt.EncodeSegment(public.N.Bytes()),
+				E:   jwt.EncodeSegment(bigEndian(public.E)),
+			})
+		case ed25519.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: AlgEdDSA,
+				Crv: "Ed25519",
+				X:   jwt.EncodeSegment(public),
+			})
+		}
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks
+}
+
+// bigEndian 返回整数去掉前导零的大端字节
+func bigEndian(n int) []byte {
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return b
+}
+
+// Load 从环境变量加载密钥：
+// 设置 JWT_KEYS_FILE 时从该 JSON 文件读取（支持多个密钥和 RS256/EdDSA），
+// 否则使用 JWT_SECRET 作为唯一的 HS256 密钥（kid 为 default），都没有设置时返回错误
+func Load() (*KeySet, error) {
+	if path := os.Getenv("JWT_KEYS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取密钥配置失败: %v", err)
+		}
+		var config Config
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("解析密钥配置失败: %v", err)
+		}
+		return NewKeySet(config, filepath.Dir(path))
+	}
+
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("未配置 JWT 签名密钥，请设置 JWT_KEYS_FILE 或 JWT_SECRET")
+	}
+	// 短密钥容易被暴力破解，只有本地开发显式设置 JWT_ALLOW_WEAK_SECRET=true 时才允许
+	if len(secret) < minSecretLength {
+		if os.Getenv("JWT_ALLOW_WEAK_SECRET") != "true" {
+			return nil, fmt.Errorf("JWT_SECRET 至少需要 %d 个字符，本地开发可设置 JWT_ALLOW_WEAK_SECRET=true", minSecretLength)
+		}
+		fmt.Printf("警告: JWT_SECRET 少于 %d 个字符，仅限本地开发使用\n", minSecretLength)
+	}
+	key := hmacKey(LegacyKeyID, secret)
+	return &KeySet{active: key, keys: map[string]*Key{LegacyKeyID: key}}, nil
+}
+
+var (
+	defaultOnce sync.Once
+	defaultKeys *KeySet
+	defaultErr  error
+)
+
+// Default 返回进程使用的密钥集合，首次调用时加载，修改配置后需重启服务
+func Default() (*KeySet, error) {
+	defaultOnce.Do(func() {
+		defaultKeys, defaultErr = Load()
+	})
+	return defaultKeys, defaultErr
+}
+
+// Sign 使用默认密钥集合签发 token
+func Sign(claims jwt.MapClaims) (string, error) {
+	ks, err := Default()
+	if err != nil {
+		return "", err
+	}
+	return ks.Sign(claims)
+}
+
+// Parse 使用默认密钥集合验证 token
+func Parse(tokenString string) (jwt.MapClaims, error) {
+	ks, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return ks.Parse(tokenString)
+}
@@ -0,0 +1,330 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// writePEM 将 DER 编码的密钥写入 dir 下的 PEM 文件，返回文件名
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// testKeys 生成一组 RS256 和 EdDSA 密钥文件
+func testKeys(t *testing.T) (dir string, rsaPriv, rsaPub, edPriv, edPub string) {
+	t.Helper()
+	dir = t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPriv = writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	rsaPub = writePEM(t, dir, "rsa.pub.pem", "PUBLIC KEY", rsaPubDER)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPrivDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPriv = writePEM(t, dir, "ed25519.pem", "PRIVATE KEY", edPrivDER)
+	edPub = writePEM(t, dir, "ed25519.pub.pem", "PUBLIC KEY", edPubDER)
+	return
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": float64(1), "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestSignAndParse(t *testing.T) {
+	dir, rsaPriv, _, edPriv, _ := testKeys(t)
+	configs := map[string]KeyConfig{
+		AlgHS256: {ID: "hs", Alg: AlgHS256, Secret: testSecret},
+		AlgRS256: {ID: "rs", Alg: AlgRS256, PrivateKeyFile: rsaPriv},
+		AlgEdDSA: {ID: "ed", Alg: AlgEdDSA, PrivateKeyFile: edPriv},
+	}
+
+	for alg, kc := range configs {
+		ks, err := NewKeySet(Config{Active: kc.ID, Keys: []KeyConfig{kc}}, dir)
+		if err != nil {
+			t.Fatalf("%s: 创建密钥失败: %v", alg, err)
+		}
+		tokenString, err := ks.Sign(testClaims())
+		if err != nil {
+			t.Fatalf("%s: 签发失败: %v", alg, err)
+		}
+
+		token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Header["kid"] != kc.ID || token.Method.Alg() != alg {
+			t.Errorf("%s: header 不正确: %v", alg, token.Header)
+		}
+
+		claims, err := ks.Parse(tokenString)
+		if err != nil {
+			t.Fatalf("%s: 验证失败: %v", alg, err)
+		}
+		if claims["user_id"] != float64(1) {
+			t.Errorf("%s: claims 不正确: %v", alg, claims)
+		}
+	}
+}
+
+func TestRotation(t *testing.T) {
+	dir, _, _, edPriv, _ := testKeys(t)
+	old, err := NewKeySet(Config{Active: "k1", Keys: []KeyConfig{
+		{ID: "k1", Alg: AlgHS256, Secret: testSecret},
+	}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := old.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 新增 k2 并切换为 active，k1 签发的 token 仍然有效
+	rotated, err := NewKeySet(Config{Active: "k2", Keys: []KeyConfig{
+		{ID: "k1", Alg: AlgHS256, Secret: testSecret},
+		{ID: "k2", Alg: AlgEdDSA, PrivateKeyFile: edPriv},
+	}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.Parse(oldToken); err != nil {
+		t.Errorf("轮换后旧 token 应仍然有效: %v", err)
+	}
+	newToken, err := rotated.Sign(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rotated.Parse(newToken); err != nil {
+		t.Errorf("新 token 验证失败: %v", err)
+	}
+
+	// 移除 k1 后旧 token 失效
+	retired, err := NewKeySet(Config{Active: "k2", Keys: []KeyConfig{
+		{ID: "k2", Alg: AlgEdDSA, PrivateKeyFile: edPriv},
+	}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := retired.Parse(oldToken); err == nil {
+		t.Error("移除旧密钥后旧 token 应失效")
+	}
+	if _, err := retired.Parse(newToken); err != nil {
+		t.Errorf("新 token 验证失败: %v", err)
+	}
+}
+
+// isValidationError 判断 err 是否由 keyfunc 返回的 target 引起
+func isValidationError(err, target error) bool {
+	var ve *jwt.ValidationError
+	return errors.As(err, &ve) && errors.Is(ve.Inner, target)
+}
+
+func TestParseRejects(t *testing.T) {
+	dir, _, rsaPub, edPriv, _ := testKeys(t)
+	_, err := NewKeySet(Config{Active: "rs", Keys: []KeyConfig{
+		{ID: "rs", Alg: AlgRS256, PublicKeyFile: rsaPub},
+		{ID: "ed", Alg: AlgEdDSA, PrivateKeyFile: edPriv},
+		{ID: "hs", Alg: AlgHS256, Secret: testSecret},
+	}}, dir)
+	if err == nil {
+		t.Fatal("只有公钥的密钥不能作为 active")
+	}
+	ks, err := NewKeySet(Config{Active: "ed", Keys: []KeyConfig{
+		{ID: "rs", Alg: AlgRS256, PublicKeyFile: rsaPub},
+		{ID: "ed", Alg: AlgEdDSA, PrivateKeyFile: edPriv},
+		{ID: "hs", Alg: AlgHS256, Secret: testSecret},
+	}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(method jwt.SigningMethod, kid interface{}, key interface{}) string {
+		token := jwt.NewWithClaims(method, testClaims())
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	// 用 RSA 公钥内容作为 HS256 密钥伪造签名
+	pubPEM, err := os.ReadFile(filepath.Join(dir, rsaPub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Parse(sign(jwt.SigningMethodHS256, "rs", pubPEM)); !isValidationError(err, ErrAlgMismatch) {
+		t.Errorf("算法混淆应被拒绝, 实际 %v", err)
+	}
+
+	// HS256 密钥签发但声明其他 kid
+	if _, err := ks.Parse(sign(jwt.SigningMethodHS256, "ed", []byte(testSecret))); !isValidationError(err, ErrAlgMismatch) {
+		t.Errorf("算法与 kid 不一致应被拒绝, 实际 %v", err)
+	}
+
+	// 未知 kid 和缺少 kid
+	if _, err := ks.Parse(sign(jwt.SigningMethodHS256, "unknown", []byte(testSecret))); !isValidationError(err, ErrUnknownKey) {
+		t.Errorf("未知 kid 应被拒绝, 实际 %v", err)
+	}
+	if _, err := ks.Parse(sign(jwt.SigningMethodHS256, nil, []byte(testSecret))); !isValidationError(err, ErrUnknownKey) {
+		t.Errorf("缺少 kid 应被拒绝, 实际 %v", err)
+	}
+
+	// 未配置的算法
+	if _, err := ks.Parse(sign(jwt.SigningMethodHS512, "hs", []byte(testSecret))); err == nil {
+		t.Error("未配置的算法应被拒绝")
+	}
+
+	// alg none
+	if _, err := ks.Parse(sign(jwt.SigningMethodNone, "hs", jwt.UnsafeAllowNoneSignatureType)); err == nil {
+		t.Error("alg none 应被拒绝")
+	}
+
+	// 正确签发的 HS256 token 可以通过
+	if _, err := ks.Parse(sign(jwt.SigningMethodHS256, "hs", []byte(testSecret))); err != nil {
+		t.Errorf("HS256 token 验证失败: %v", err)
+	}
+}
+
+func TestNewKeySetValidation(t *testing.T) {
+	dir, rsaPriv, _, _, _ := testKeys(t)
+	cases := map[string]Config{
+		"密钥过短":       {Active: "a", Keys: []KeyConfig{{ID: "a", Alg: AlgHS256, Secret: "short"}}},
+		"kid 重复":     {Active: "a", Keys: []KeyConfig{{ID: "a", Alg: AlgHS256, Secret: testSecret}, {ID: "a", Alg: AlgHS256, Secret: testSecret}}},
+		"缺少 kid":     {Active: "", Keys: []KeyConfig{{Alg: AlgHS256, Secret: testSecret}}},
+		"不支持的算法":     {Active: "a", Keys: []KeyConfig{{ID: "a", Alg: "none"}}},
+		"缺少密钥文件":     {Active: "a", Keys: []KeyConfig{{ID: "a", Alg: AlgRS256}}},
+		"密钥类型不符":     {Active: "a", Keys: []KeyConfig{{ID: "a", Alg: AlgEdDSA, PrivateKeyFile: rsaPriv}}},
+		"active 不存在": {Active: "b", Keys: []KeyConfig{{ID: "a", Alg: AlgHS256, Secret: testSecret}}},
+	}
+	for name, config := range cases {
+		if _, err := NewKeySet(config, dir); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	dir, rsaPriv, _, _, edPub := testKeys(t)
+	ks, err := NewKeySet(Config{Active: "rs", Keys: []KeyConfig{
+		{ID: "rs", Alg: AlgRS256, PrivateKeyFile: rsaPriv},
+		{ID: "ed", Alg: AlgEdDSA, PublicKeyFile: edPub},
+		{ID: "hs", Alg: AlgHS256, Secret: testSecret},
+	}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := ks.JWKS()
+	if len(jwks) != 2 {
+		t.Fatalf("JWKS 应只包含 2 个公钥, 实际 %+v", jwks)
+	}
+
+	ed, rs := jwks[0], jwks[1]
+	if ed.Kid != "ed" || ed.Kty != "OKP" || ed.Crv != "Ed25519" || ed.Alg != AlgEdDSA || ed.X == "" {
+		t.Errorf("Ed25519 公钥不正确: %+v", ed)
+	}
+	if x, err := jwt.DecodeSegment(ed.X); err != nil || len(x) != ed25519.PublicKeySize {
+		t.Errorf("Ed25519 公钥长度不正确: %v", err)
+	}
+	if rs.Kid != "rs" || rs.Kty != "RSA" || rs.Alg != AlgRS256 || rs.N == "" || rs.E != "AQAB" {
+		t.Errorf("RSA 公钥不正确: %+v", rs)
+	}
+
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw []map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range raw {
+		if _, ok := key["d"]; ok {
+			t.Errorf("JWKS 不应包含私钥: %v", key)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("JWT_KEYS_FILE", "")
+	t.Setenv("JWT_SECRET", "")
+	if _, err := Load(); err == nil {
+		t.Error("未配置密钥时应返回错误")
+	}
+
+	t.Setenv("JWT_SECRET", "short-secret")
+	t.Setenv("JWT_ALLOW_WEAK_SECRET", "")
+	if _, err := Load(); err == nil {
+		t.Error("JWT_SECRET 过短时应返回错误")
+	}
+	t.Setenv("JWT_ALLOW_WEAK_SECRET", "true")
+	if _, err := Load(); err != nil {
+		t.Errorf("设置 JWT_ALLOW_WEAK_SECRET 后应允许短密钥: %v", err)
+	}
+	t.Setenv("JWT_ALLOW_WEAK_SECRET", "")
+
+	t.Setenv("JWT_SECRET", testSecret)
+	ks, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks.active.ID != LegacyKeyID {
+		t.Errorf("JWT_SECRET 密钥的 kid 应为 %s, 实际 %s", LegacyKeyID, ks.active.ID)
+	}
+
+	dir, _, _, edPriv, _ := testKeys(t)
+	data, _ := json.Marshal(Config{Active: "ed", Keys: []KeyConfig{
+		{ID: LegacyKeyID, Alg: AlgHS256, Secret: testSecret},
+		{ID: "ed", Alg: AlgEdDSA, PrivateKeyFile: edPriv},
+	}})
+	path := filepath.Join(dir, "keys.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JWT_KEYS_FILE", path)
+	ks, err = Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ks.active.ID != "ed" || len(ks.keys) != 2 {
+		t.Errorf("密钥配置文件加载不正确: active=%s keys=%d", ks.active.ID, len(ks.keys))
+	}
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"calculator/internal/jwtkeys"
+	"context"
+	"log"
 	"net/http"
 	"strings"
 
@@ -8,8 +11,6 @@ import (
 	"github.com/golang-jwt/jwt"
 )
 
-var jwtSecret = []byte("your-secret-key") // TODO: 从配置文件读取
-
 // CORS 跨域中间件
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -26,6 +27,14 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
+// sessionRevoked 判断访问令牌所属的会话（sid）是否已吊销，未设置时不检查
+var sessionRevoked func(ctx context.Context, sid string) (bool, error)
+
+// SetSessionRevocationCheck 设置会话吊销检查，已登出或被吊销的会话签发的访问令牌不再通过认证
+func SetSessionRevocationCheck(check func(ctx context.Context, sid string) (bool, error)) {
+	sessionRevoked = check
+}
+
 // AuthRequired 验证JWT token的中间件
 func AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -56,8 +65,24 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
+		// 检查会话是否已登出或被吊销
+		sid, _ := claims["sid"].(string)
+		if sid != "" && sessionRevoked != nil {
+			revoked, err := sessionRevoked(c.Request.Context(), sid)
+			if err != nil {
+				log.Printf("检查会话状态失败: %v", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify session"})
+				return
+			}
+			if revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+				return
+			}
+		}
+
 		// Set user info in context
 		c.Set("user_id", uint(userID))
+		c.Set("session_id", sid)
 		c.Set("username", claims["username"])
 		c.Set("role", claims["role"])
 
@@ -65,21 +90,9 @@ func AuthRequired() gin.HandlerFunc {
 	}
 }
 
-// validateToken 验证JWT token
+// validateToken 验证JWT token，token 必须带有已配置的 kid，且签名算法与该密钥的算法一致
 func validateToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
-	}
-
-	return nil, jwt.ErrSignatureInvalid
+	return jwtkeys.Parse(tokenString)
 }
 
 // RoleMiddleware 角色验证中间件
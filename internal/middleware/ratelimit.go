@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"calculator/internal/redis"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitRule 限流规则（令牌桶）
+type RateLimitRule struct {
+	Name  string  // 规则名称，用于区分不同路由组的桶
+	Rate  float64 // 每秒补充的令牌数
+	Burst int     // 桶容量，即允许的瞬时突发请求数
+}
+
+// Limiter 限流器接口
+type Limiter interface {
+	// Allow 尝试为 key 取一个令牌，返回是否放行以及建议的重试等待时间
+	Allow(ctx context.Context, key string, rule RateLimitRule) (bool, time.Duration, error)
+}
+
+// NewLimiter 创建限流器，redisClient 为 nil 时使用单机内存限流
+func NewLimiter(redisClient *redis.Redis) Limiter {
+	memory := NewMemoryLimiter()
+	if redisClient == nil {
+		return memory
+	}
+	return &redisLimiter{redis: redisClient, fallback: memory}
+}
+
+// redisLimiter 基于 Redis 的分布式限流器，Redis 不可用时退化为内存限流
+type redisLimiter struct {
+	redis    *redis.Redis
+	fallback *MemoryLimiter
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string, rule RateLimitRule) (bool, time.Duration, error) {
+	allowed, wait, err := l.redis.TakeToken(ctx, rule.Name+":"+key, rule.Rate, rule.Burst)
+	if err != nil {
+		log.Printf("Redis 限流失败，使用内存限流: %v", err)
+		return l.fallback.Allow(ctx, key, rule)
+	}
+	return allowed, wait, nil
+}
+
+// memoryLimiterSweepSize 内存限流器触发清理的最小桶数量
+const memoryLimiterSweepSize = 10000
+
+// MemoryLimiter 单机内存令牌桶限流器
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	sweepAt int // 桶数量超过该值时清理，清理后按剩余数量翻倍，使清理的开销均摊到每次插入
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	idle   time.Duration // 所属规则补满一个空桶所需的时间，超过该时间未使用的桶可以删除
+}
+
+// NewMemoryLimiter 创建内存限流器
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*tokenBucket),
+		sweepAt: memoryLimiterSweepSize,
+		now:     time.Now,
+	}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, rule RateLimitRule) (bool, time.Duration, error) {
+	if rule.Rate <= 0 || rule.Burst <= 0 {
+		return false, 0, fmt.Errorf("无效的限流参数: rate=%v, burst=%d", rule.Rate, rule.Burst)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	bucketKey := rule.Name + ":" + key
+	bucket, ok := l.buckets[bucketKey]
+	if !ok {
+		bucket = &tokenBucket{
+			tokens: float64(rule.Burst),
+			last:   now,
+			idle:   time.Duration(float64(rule.Burst) / rule.Rate * float64(time.Second)),
+		}
+		l.buckets[bucketKey] = bucket
+		// 桶数量过多时顺带清理已经补满的桶，避免 map 无限增长
+		if len(l.buckets) > l.sweepAt {
+			l.cleanup(now)
+			l.sweepAt = max(memoryLimiterSweepSize, 2*len(l.buckets))
+		}
+	}
+
+	elapsed := now.Sub(bucket.last).Seconds()
+	if elapsed > 0 {
+		bucket.tokens = math.Min(float64(rule.Burst), bucket.tokens+elapsed*rule.Rate)
+		bucket.last = now
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / rule.Rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// cleanup 删除已经补满的桶，每个桶按所属规则的补满时间判断
+func (l *MemoryLimiter) cleanup(now time.Time) {
+	for k, b := range l.buckets {
+		if now.Sub(b.last) > b.idle {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// rateLimitKey 返回限流计数的 key：已登录时按用户计数，避免同一出口 IP 下的整个班级共用额度；未登录时按客户端 IP 计数
+func rateLimitKey(c *gin.Context) string {
+	if userID := c.GetUint("user_id"); userID != 0 {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimit 限流中间件，已登录用户按用户计数，否则按客户端 IP 计数
+// 对需要登录的路由，应放在 AuthRequired 之后以便获取 user_id
+func RateLimit(limiter Limiter, rule RateLimitRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, wait, err := limiter.Allow(c.Request.Context(), rateLimitKey(c), rule)
+		if err != nil {
+			// 限流器异常时放行，不影响正常业务
+			log.Printf("限流检查失败: %v", err)
+			c.Next()
+			return
+		}
+		if !allowed {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "请求过于频繁，请稍后再试"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+
+	rule := RateLimitRule{Name: "test", Rate: 1, Burst: 3}
+	ctx := context.Background()
+
+	// 桶容量内的请求全部放行
+	for i := 0; i < 3; i++ {
+		if allowed, _, _ := l.Allow(ctx, "ip:1", rule); !allowed {
+			t.Fatalf("第 %d 次请求应放行", i+1)
+		}
+	}
+
+	// 超出容量后拒绝，并给出重试时间
+	allowed, wait, err := l.Allow(ctx, "ip:1", rule)
+	if err != nil {
+		t.Fatalf("意外错误: %v", err)
+	}
+	if allowed {
+		t.Fatalf("超出桶容量的请求应被拒绝")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("重试时间不正确: %v", wait)
+	}
+
+	// 不同 key 互不影响
+	if allowed, _, _ := l.Allow(ctx, "ip:2", rule); !allowed {
+		t.Errorf("其他 key 的请求应放行")
+	}
+
+	// 经过一秒补充一个令牌
+	now = now.Add(time.Second)
+	if allowed, _, _ := l.Allow(ctx, "ip:1", rule); !allowed {
+		t.Errorf("补充令牌后请求应放行")
+	}
+	if allowed, _, _ := l.Allow(ctx, "ip:1", rule); allowed {
+		t.Errorf("令牌用尽后请求应被拒绝")
+	}
+}
+
+func TestMemoryLimiter_CleanupPerRule(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	login := RateLimitRule{Name: "login", Rate: 1.0 / 6, Burst: 5}
+	answer := RateLimitRule{Name: "answer", Rate: 2, Burst: 10}
+
+	for i := 0; i < login.Burst; i++ {
+		l.Allow(ctx, "ip:1", login)
+	}
+	l.Allow(ctx, "user:1", answer)
+
+	// answer 桶 5 秒补满，login 桶需要 30 秒，10 秒后清理只应删除 answer 桶
+	now = now.Add(10 * time.Second)
+	l.cleanup(now)
+	if _, ok := l.buckets["answer:user:1"]; ok {
+		t.Errorf("已补满的 answer 桶应被清理")
+	}
+	if _, ok := l.buckets["login:ip:1"]; !ok {
+		t.Fatalf("未补满的 login 桶不应被清理")
+	}
+	// 10 秒只补充了约 1.7 个令牌
+	l.Allow(ctx, "ip:1", login)
+	if allowed, _, _ := l.Allow(ctx, "ip:1", login); allowed {
+		t.Errorf("login 桶的限额不应因清理而重置")
+	}
+}
+
+func TestMemoryLimiter_SweepAmortized(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewMemoryLimiter()
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// 桶都未补满时清理不会删除任何桶，清理阈值应随桶数量增长，而不是每次插入都清理
+	rule := RateLimitRule{Name: "test", Rate: 0.001, Burst: 1}
+	for i := 0; i <= memoryLimiterSweepSize; i++ {
+		l.Allow(ctx, "ip:"+strconv.Itoa(i), rule)
+	}
+	if l.sweepAt < 2*memoryLimiterSweepSize {
+		t.Errorf("清理后阈值应翻倍, 实际 %d", l.sweepAt)
+	}
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// UserAchievement 用户获得的成就，每个成就每人只能获得一次
+type UserAchievement struct {
+	ID       uint      `json:"-" gorm:"primaryKey"`
+	UserID   uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_achievement"`
+	Code     string    `json:"code" gorm:"type:varchar(50);not null;uniqueIndex:idx_user_achievement"`
+	EarnedAt time.Time `json:"earned_at" gorm:"not null"`
+}
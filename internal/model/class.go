@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// School 学校，由管理员创建，班级可归属于某个学校
+type School struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"type:varchar(100);uniqueIndex;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+}
+
+// Class 班级模型，由教师创建
+type Class struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TeacherID uint      `json:"teacher_id" gorm:"not null;index"`
+	SchoolID  *uint     `json:"school_id,omitempty" gorm:"index"`
+	Name      string    `json:"name" gorm:"type:varchar(50);not null"`
+	JoinCode  *string   `json:"join_code,omitempty" gorm:"type:varchar(20);uniqueIndex"` // 学生注册时使用的班级码
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+// Enrollment 学生加入班级的记录
+type Enrollment struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ClassID   uint      `json:"class_id" gorm:"not null;uniqueIndex:idx_class_student"`
+	StudentID uint      `json:"student_id" gorm:"not null;uniqueIndex:idx_class_student;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 金币流水类型
+const (
+	CoinReasonAnswer   = "answer"   // 答对题目
+	CoinReasonStreak   = "streak"   // 连续练习达到里程碑
+	CoinReasonHomework = "homework" // 完成作业
+	CoinReasonPurchase = "purchase" // 兑换装扮
+)
+
+// ErrLedgerAppendOnly 金币流水只能追加，不能修改或删除
+var ErrLedgerAppendOnly = errors.New("金币流水只能追加，不能修改或删除")
+
+// CoinWallet 用户的金币余额，与金币流水在同一事务中更新，余额应始终等于流水金额之和
+type CoinWallet struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Balance   int64     `json:"balance" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+// CoinTransaction 金币流水，每次余额变动追加一条，记录变动后的余额用于核对
+// 同一用户的同一来源（Reason + Reference）只记录一次，重复发放会被拒绝
+type CoinTransaction struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_coin_source"`
+	Amount       int64     `json:"amount" gorm:"not null"` // 正数为获得，负数为消费
+	BalanceAfter int64     `json:"balance_after" gorm:"not null"`
+	Reason       string    `json:"reason" gorm:"type:varchar(20);not null;uniqueIndex:idx_coin_source"`
+	Reference    string    `json:"reference" gorm:"type:varchar(64);not null;uniqueIndex:idx_coin_source"` // 来源，如 question:123、homework:5、item:avatar_cat
+	CreatedAt    time.Time `json:"created_at" gorm:"not null"`
+}
+
+// BeforeUpdate 禁止修改金币流水
+func (CoinTransaction) BeforeUpdate(*gorm.DB) error {
+	return ErrLedgerAppendOnly
+}
+
+// BeforeDelete 禁止删除金币流水
+func (CoinTransaction) BeforeDelete(*gorm.DB) error {
+	return ErrLedgerAppendOnly
+}
+
+// UserItem 用户兑换的装扮，每类装扮同时只能使用一个
+type UserItem struct {
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_item"`
+	ItemCode    string    `json:"item_code" gorm:"type:varchar(50);not null;uniqueIndex:idx_user_item"`
+	Category    string    `json:"category" gorm:"type:varchar(20);not null"`
+	Equipped    bool      `json:"equipped" gorm:"not null;default:false"`
+	PurchasedAt time.Time `json:"purchased_at" gorm:"not null"`
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 考试作答状态
+const (
+	ExamStatusInProgress = "in_progress"
+	ExamStatusSubmitted  = "submitted"
+	ExamStatusTimedOut   = "timed_out"
+)
+
+// ExamQuestion 试卷中的一道题目
+type ExamQuestion struct {
+	Index      int    `json:"index"`
+	Expression string `json:"expression"`
+	Answer     int    `json:"answer"`
+}
+
+// ExamQuestions 试卷题目列表，以 JSON 形式存储
+type ExamQuestions []ExamQuestion
+
+// Value 实现 driver.Valuer
+func (q ExamQuestions) Value() (driver.Value, error) {
+	return marshalJSONColumn(q)
+}
+
+// Scan 实现 sql.Scanner
+func (q *ExamQuestions) Scan(value interface{}) error {
+	return unmarshalJSONColumn(value, q)
+}
+
+// ExamAnswers 学生答案，题目序号 -> 答案，以 JSON 形式存储
+type ExamAnswers map[int]int
+
+// Value 实现 driver.Valuer
+func (a ExamAnswers) Value() (driver.Value, error) {
+	return marshalJSONColumn(a)
+}
+
+// Scan 实现 sql.Scanner
+func (a *ExamAnswers) Scan(value interface{}) error {
+	return unmarshalJSONColumn(value, a)
+}
+
+// ExamPaper 试卷模型，题目在创建时固定
+type ExamPaper struct {
+	ID          uint          `json:"id" gorm:"primaryKey"`
+	TeacherID   uint          `json:"teacher_id" gorm:"not null;index"`
+	Title       string        `json:"title" gorm:"type:varchar(100);not null"`
+	Difficulty  string        `json:"difficulty" gorm:"type:varchar(20);not null"`
+	TimeLimit   int           `json:"time_limit" gorm:"not null"` // 限时（秒）
+	AllowRetake bool          `json:"allow_retake" gorm:"not null;default:false"`
+	Questions   ExamQuestions `json:"questions" gorm:"type:text;not null"`
+	CreatedAt   time.Time     `json:"created_at" gorm:"not null"`
+	UpdatedAt   time.Time     `json:"updated_at" gorm:"not null"`
+}
+
+// ExamAttempt 考试作答记录
+type ExamAttempt struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	PaperID       uint        `json:"paper_id" gorm:"not null;index"`
+	UserID        uint        `json:"user_id" gorm:"not null;index"`
+	Status        string      `json:"status" gorm:"type:varchar(20);not null;index"`
+	Answers       ExamAnswers `json:"answers" gorm:"type:text"`
+	Total         int         `json:"total" gorm:"not null"`                        // 总题数
+	Correct       int         `json:"correct" gorm:"not null;default:0"`            // 答对题数
+	Score         float64     `json:"score" gorm:"not null;default:0"`              // 百分制得分
+	RetakeAllowed bool        `json:"retake_allowed" gorm:"not null;default:false"` // 教师是否允许重考
+	StartedAt     time.Time   `json:"started_at" gorm:"not null"`
+	Deadline      time.Time   `json:"deadline" gorm:"not null"`
+	SubmittedAt   *time.Time  `json:"submitted_at"`
+	CreatedAt     time.Time   `json:"created_at" gorm:"not null"`
+	UpdatedAt     time.Time   `json:"updated_at" gorm:"not null"`
+}
+
+// marshalJSONColumn 将值序列化为 JSON 字符串存入数据库
+func marshalJSONColumn(v interface{}) (driver.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// unmarshalJSONColumn 从数据库读取 JSON 并反序列化
+func unmarshalJSONColumn(value interface{}, v interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return fmt.Errorf("无法解析 JSON 字段: %T", value)
+	}
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// 每日目标类型
+const (
+	GoalTypeQuestions = "questions" // 每天答题数
+	GoalTypeMinutes   = "minutes"   // 每天练习分钟数（按答题用时累计）
+)
+
+// ValidGoalType 判断目标类型是否合法
+func ValidGoalType(goalType string) bool {
+	return goalType == GoalTypeQuestions || goalType == GoalTypeMinutes
+}
+
+// DailyGoal 学生的每日目标，由学生自己或任课教师设置
+type DailyGoal struct {
+	ID     uint   `json:"-" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"not null;uniqueIndex"`
+	Type   string `json:"type" gorm:"type:varchar(20);not null"`
+	Target int    `json:"target" gorm:"not null"`
+	// ReminderTime 提醒时间（HH:MM，按用户时区），到时仍未完成目标时提醒，为空表示不提醒
+	ReminderTime string    `json:"reminder_time" gorm:"type:varchar(5);not null;default:''"`
+	SetBy        uint      `json:"set_by" gorm:"not null"` // 设置目标的用户（学生本人或教师）
+	CreatedAt    time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"not null"`
+}
+
+// GoalRecord 某一天的目标完成情况，保存当天的目标，之后修改目标不影响以前的记录
+type GoalRecord struct {
+	ID          uint       `json:"-" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_goal_record_day"`
+	Date        string     `json:"date" gorm:"type:char(10);not null;uniqueIndex:idx_goal_record_day"` // YYYY-MM-DD，按用户时区
+	Type        string     `json:"type" gorm:"type:varchar(20);not null"`
+	Target      int        `json:"target" gorm:"not null"`
+	Progress    int        `json:"progress" gorm:"not null;default:0"`
+	Completed   bool       `json:"completed" gorm:"not null;default:false"`
+	CompletedAt *time.Time `json:"completed_at"`
+	RemindedAt  *time.Time `json:"reminded_at"` // 客户端已展示提醒的时间，当天不再提醒
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"not null"`
+}
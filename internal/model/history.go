@@ -1,6 +1,16 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 答题记录来源
+const (
+	HistorySourceServer = "server" // 服务端发放题目并判题后保存
+	HistorySourceClient = "client" // 客户端通过 POST /api/history 上报，不计入练习成绩
+)
 
 // HistoryRecord 历史记录模型
 type HistoryRecord struct {
@@ -11,8 +21,24 @@ type HistoryRecord struct {
 	UserAnswer       int       `json:"user_answer" gorm:"not null"`
 	CorrectAnswer    int       `json:"correct_answer" gorm:"not null"`
 	IsCorrect        bool      `json:"is_correct" gorm:"not null"`
+	TimedOut         bool      `json:"timed_out" gorm:"not null;default:false"` // 超过单题限时，按错误计
 	Difficulty       string    `json:"difficulty" gorm:"not null"`
 	TimeSpent        float64   `json:"time_spent" gorm:"not null"`
+	SessionID        *uint     `json:"session_id,omitempty" gorm:"index"`
+	SprintID         *uint     `json:"sprint_id,omitempty" gorm:"index"`
+	ExamAttemptID    *uint     `json:"exam_attempt_id,omitempty" gorm:"index"`
+	Source           string    `json:"source" gorm:"type:varchar(10);not null;default:'server';index"`
 	CreatedAt        time.Time `json:"created_at" gorm:"not null"`
 	UpdatedAt        time.Time `json:"updated_at" gorm:"not null"`
 }
+
+// CountedAnswers 计入练习统计（排行榜、成就、每日目标、练习日历）的答题记录：
+// 服务端判题保存且不属于考试，客户端上报的记录不计入
+func CountedAnswers(db *gorm.DB) *gorm.DB {
+	return db.Where("source = ? AND exam_attempt_id IS NULL", HistorySourceServer)
+}
+
+// Counted 判断已加载的答题记录是否计入练习统计，与 CountedAnswers 的查询条件一致
+func (r *HistoryRecord) Counted() bool {
+	return r.Source == HistorySourceServer && r.ExamAttemptID == nil
+}
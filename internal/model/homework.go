@@ -0,0 +1,40 @@
+package model
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// Templates 题型列表（见 drill.Templates），以 JSON 形式存储
+type Templates []string
+
+// Value 实现 driver.Valuer
+func (t Templates) Value() (driver.Value, error) {
+	return marshalJSONColumn(t)
+}
+
+// Scan 实现 sql.Scanner
+func (t *Templates) Scan(value interface{}) error {
+	return unmarshalJSONColumn(value, t)
+}
+
+// Homework 教师布置给班级的练习作业
+type Homework struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ClassID       uint      `json:"class_id" gorm:"not null;index"`
+	TeacherID     uint      `json:"teacher_id" gorm:"not null;index"`
+	Title         string    `json:"title" gorm:"type:varchar(100);not null"`
+	Difficulty    string    `json:"difficulty" gorm:"type:varchar(20);not null"`
+	QuestionCount int       `json:"question_count" gorm:"not null"`
+	Templates     Templates `json:"templates" gorm:"type:varchar(255)"`   // 题型组合，为空表示该难度的全部题型
+	TimeLimit     int       `json:"time_limit" gorm:"not null;default:0"` // 单题限时（秒），0 表示使用难度默认值
+	OpensAt       time.Time `json:"opens_at" gorm:"not null"`
+	DueAt         time.Time `json:"due_at" gorm:"not null"`
+	CreatedAt     time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"not null"`
+}
+
+// TableName 指定表名
+func (Homework) TableName() string {
+	return "homeworks"
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// InviteCode 管理员发放的注册邀请码，用于创建教师等受控角色的账号
+type InviteCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Code      string     `json:"code" gorm:"type:varchar(20);uniqueIndex;not null"`
+	Role      string     `json:"role" gorm:"type:varchar(20);not null"`
+	SchoolID  *uint      `json:"school_id,omitempty"` // 使用该邀请码注册的教师所在的学校
+	CreatedBy uint       `json:"created_by" gorm:"not null"`
+	UsedBy    *uint      `json:"used_by"`
+	UsedAt    *time.Time `json:"used_at"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null"`
+}
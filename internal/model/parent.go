@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// ParentLink 家长与学生的关联
+type ParentLink struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ParentID  uint      `json:"parent_id" gorm:"not null;uniqueIndex:idx_parent_student"`
+	StudentID uint      `json:"student_id" gorm:"not null;uniqueIndex:idx_parent_student;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+}
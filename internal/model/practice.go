@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// 练习会话状态
+const (
+	PracticeStatusInProgress = "in_progress"
+	PracticeStatusFinished   = "finished"
+)
+
+// PracticeSession 练习会话模型，一次练习包含若干道题目
+type PracticeSession struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"not null;index;uniqueIndex:idx_user_homework"`
+	Difficulty    string     `json:"difficulty" gorm:"type:varchar(20);not null"`
+	QuestionCount int        `json:"question_count" gorm:"not null"`       // 计划题数
+	Templates     Templates  `json:"templates" gorm:"type:varchar(255)"`   // 题型组合，为空表示该难度的全部题型
+	TimeLimit     int        `json:"time_limit" gorm:"not null;default:0"` // 单题限时（秒），0 表示使用难度默认值
+	Issued        int        `json:"issued" gorm:"not null;default:0"`     // 已发放题数
+	Answered      int        `json:"answered" gorm:"not null;default:0"`   // 已作答题数
+	Score         int        `json:"score" gorm:"not null;default:0"`      // 答对题数
+	Accuracy      float64    `json:"accuracy" gorm:"not null;default:0"`   // 正确率（百分比）
+	Duration      float64    `json:"duration" gorm:"not null;default:0"`   // 用时（秒）
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	HomeworkID    *uint      `json:"homework_id,omitempty" gorm:"index;uniqueIndex:idx_user_homework"` // 作业对应的练习，每个学生每份作业只有一个
+	Late          bool       `json:"late" gorm:"not null;default:false"`                               // 作业是否在截止时间后完成
+	StartedAt     time.Time  `json:"started_at" gorm:"not null"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"not null"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"not null"`
+}
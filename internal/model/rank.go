@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// RankSnapshot 每日存档的用户排名，用于绘制排名变化趋势
+type RankSnapshot struct {
+	ID      uint      `json:"-" gorm:"primaryKey"`
+	UserID  uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_rank_snapshot"`
+	Period  string    `json:"period" gorm:"type:varchar(20);not null;uniqueIndex:idx_rank_snapshot"`
+	Scope   string    `json:"scope" gorm:"type:varchar(20);not null;uniqueIndex:idx_rank_snapshot"`
+	ScopeID uint      `json:"scope_id" gorm:"not null;uniqueIndex:idx_rank_snapshot"`
+	Date    time.Time `json:"date" gorm:"type:date;not null;uniqueIndex:idx_rank_snapshot;index"`
+	Rank    int       `json:"rank" gorm:"not null"`
+	Score   float64   `json:"score" gorm:"not null"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// 赛季状态
+const (
+	SeasonStatusActive   = "active"
+	SeasonStatusArchived = "archived"
+)
+
+// Season 排行榜赛季，默认按自然月自动创建，管理员也可以按学期等自定义
+// 赛季结束后最终排名存入 SeasonStanding，Redis 中的赛季排行榜随之删除
+type Season struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Name       string     `json:"name" gorm:"type:varchar(50);uniqueIndex;not null"`
+	StartsAt   time.Time  `json:"starts_at" gorm:"not null;index"`
+	EndsAt     time.Time  `json:"ends_at" gorm:"not null;index"`
+	Status     string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	Auto       bool       `json:"auto" gorm:"not null;default:false"` // 是否为系统自动创建的月度赛季
+	ArchivedAt *time.Time `json:"archived_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"not null"`
+}
+
+// SeasonStanding 赛季结束时的最终排名
+type SeasonStanding struct {
+	ID       uint    `json:"-" gorm:"primaryKey"`
+	SeasonID uint    `json:"season_id" gorm:"not null;uniqueIndex:idx_season_scope_user"`
+	Scope    string  `json:"scope" gorm:"type:varchar(20);not null;uniqueIndex:idx_season_scope_user"`
+	ScopeID  uint    `json:"scope_id" gorm:"not null;uniqueIndex:idx_season_scope_user"`
+	UserID   uint    `json:"user_id" gorm:"not null;uniqueIndex:idx_season_scope_user;index"`
+	Username string  `json:"username" gorm:"type:varchar(50);not null"`
+	Rank     int     `json:"rank" gorm:"not null"`
+	Score    float64 `json:"score" gorm:"not null"`
+}
@@ -6,12 +6,17 @@ import (
 	"gorm.io/gorm"
 )
 
-// Session 会话模型
+// Session 会话模型，每条记录对应一个刷新令牌
+// Token 保存刷新令牌的 SHA-256 哈希；每次刷新都会标记旧令牌已使用并生成新令牌，
+// 同一次登录产生的令牌属于同一个 FamilyID，登出或发现令牌被重复使用时整个 FamilyID 一起吊销
 type Session struct {
 	gorm.Model
-	UserID    uint      `gorm:"not null" json:"user_id"`
-	Token     string    `gorm:"uniqueIndex;size:255;not null" json:"token"`
-	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	Token     string     `gorm:"uniqueIndex;size:255;not null" json:"-"`
+	FamilyID  string     `gorm:"type:varchar(64);not null;default:'';index" json:"family_id"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`    // 已用于刷新，再次使用视为令牌泄露
+	RevokedAt *time.Time `json:"revoked_at"` // 已吊销
 }
 
 // TableName 指定表名
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// 冲刺状态
+const (
+	SprintStatusInProgress = "in_progress"
+	SprintStatusFinished   = "finished"
+)
+
+// SprintResult 限时冲刺成绩模型
+type SprintResult struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Difficulty string     `json:"difficulty" gorm:"type:varchar(20);not null"`
+	TimeLimit  int        `json:"time_limit" gorm:"not null"`         // 限时（秒）
+	Answered   int        `json:"answered" gorm:"not null;default:0"` // 作答题数
+	Correct    int        `json:"correct" gorm:"not null;default:0"`  // 答对题数
+	Accuracy   float64    `json:"accuracy" gorm:"not null;default:0"` // 正确率（百分比）
+	Status     string     `json:"status" gorm:"type:varchar(20);not null;index"`
+	StartedAt  time.Time  `json:"started_at" gorm:"not null"`
+	EndsAt     time.Time  `json:"ends_at" gorm:"not null"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"not null"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"not null"`
+}
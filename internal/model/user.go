@@ -4,10 +4,35 @@ import (
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+	RoleParent  = "parent"
+	RoleAdmin   = "admin"
+)
+
+// ValidRole 判断角色是否合法
+func ValidRole(role string) bool {
+	switch role {
+	case RoleStudent, RoleTeacher, RoleParent, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
 // User 用户模型
 type User struct {
 	gorm.Model
 	Username string `json:"username" gorm:"type:varchar(50);uniqueIndex;not null"`
 	Password string `json:"-" gorm:"type:varchar(255);not null"`
 	Role     string `json:"role" gorm:"type:varchar(20);not null"`
+	// 以下字段由教师导入名单时填写
+	Name      string `json:"name,omitempty" gorm:"type:varchar(50)"`
+	StudentNo string `json:"student_no,omitempty" gorm:"type:varchar(50);index"`
+	// SchoolID 教师所在的学校，由管理员通过邀请码或教师学校接口指定，教师只能在该学校下创建班级
+	SchoolID *uint `json:"school_id,omitempty" gorm:"index"`
+	// TimeZone IANA 时区名（如 Asia/Shanghai），决定连续练习天数等按天统计的“今天”，为空时使用服务器时区
+	TimeZone string `json:"time_zone" gorm:"type:varchar(64);not null;default:''"`
 }
@@ -0,0 +1,198 @@
+package redis
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ActivityKeyPrefix 每日练习记录的 key 前缀：
+// activity:<用户ID> 为 bitmap，第 n 位表示 activityEpoch 之后第 n 天（按用户时区）是否答过题；
+// activity:<用户ID>:counts 为 hash，记录每天（YYYY-MM-DD）的答题数，用于日历热力图
+const ActivityKeyPrefix = "activity:"
+
+// activityEpoch bitmap 第 0 位对应的日期，更早的答题不记录
+var activityEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Streak 连续练习天数
+type Streak struct {
+	Current     int  `json:"current"`      // 截至今天（今天还没练习时截至昨天）的连续天数
+	Longest     int  `json:"longest"`      // 历史最长连续天数
+	ActiveToday bool `json:"active_today"` // 今天是否已练习
+}
+
+// ActivityDay 日历热力图中的一天
+type ActivityDay struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// activityKey 返回用户的每日练习 bitmap key
+func activityKey(userID uint) string {
+	return fmt.Sprintf("%s%d", ActivityKeyPrefix, userID)
+}
+
+// activityCountKey 返回用户的每日答题数 key
+func activityCountKey(userID uint) string {
+	return activityKey(userID) + ":counts"
+}
+
+// activityDay 返回 t 在 loc 时区的日期对应的 bitmap 位
+func activityDay(t time.Time, loc *time.Location) int64 {
+	year, month, day := t.In(loc).Date()
+	return int64(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Sub(activityEpoch) / (24 * time.Hour))
+}
+
+// activityDate 返回 bitmap 位对应的日期（YYYY-MM-DD）
+func activityDate(day int64) string {
+	return activityEpoch.AddDate(0, 0, int(day)).Format("2006-01-02")
+}
+
+// bitSet 判断 bitmap 的第 offset 位是否为 1，位顺序与 Redis SETBIT 一致（每个字节从高位开始）
+func bitSet(bitmap []byte, offset int64) bool {
+	if offset < 0 || offset/8 >= int64(len(bitmap)) {
+		return false
+	}
+	return bitmap[offset/8]&(0x80>>(offset%8)) != 0
+}
+
+// streakFromBitmap 根据 bitmap 计算 today 时的连续练习天数
+func streakFromBitmap(bitmap []byte, today int64) Streak {
+	streak := Streak{ActiveToday: bitSet(bitmap, today)}
+
+	// 今天还没练习时不中断连续天数，从昨天开始往前数
+	day := today
+	if !streak.ActiveToday {
+		day--
+	}
+	for bitSet(bitmap, day) {
+		streak.Current++
+		day--
+	}
+
+	run := 0
+	for offset := int64(0); offset < int64(len(bitmap))*8; offset++ {
+		if bitSet(bitmap, offset) {
+			run++
+			streak.Longest = max(streak.Longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return streak
+}
+
+// RecordActivity 记录一道答题，按用户时区计入答题当天，不计入练习统计的记录（见 model.CountedAnswers）跳过
+// 用户第一次记录时从 MySQL 补齐以前的答题记录（包括刚保存的这一条）
+func (r *Redis) RecordActivity(ctx context.Context, record *model.HistoryRecord) error {
+	if !record.Counted() {
+		return nil
+	}
+	userID, at := record.UserID, record.CreatedAt
+	loc := r.UserLocation(ctx, userID)
+
+	exists, err := r.Client.Exists(ctx, activityKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("记录每日练习失败: %v", err)
+	}
+	if exists == 0 {
+		return r.backfillActivity(ctx, userID, loc)
+	}
+
+	day := activityDay(at, loc)
+	if day < 0 {
+		return nil
+	}
+	_, err = r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetBit(ctx, activityKey(userID), day, 1)
+		pipe.HIncrBy(ctx, activityCountKey(userID), activityDate(day), 1)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("记录每日练习失败: %v", err)
+	}
+	return nil
+}
+
+// backfillActivity 从 MySQL 的答题记录重建用户的每日练习记录，与 RecordActivity 计入相同的记录
+func (r *Redis) backfillActivity(ctx context.Context, userID uint, loc *time.Location) error {
+	var answeredAt []time.Time
+	if err := database.DB.Model(&model.HistoryRecord{}).Scopes(model.CountedAnswers).Where("user_id = ? AND created_at >= ?", userID, activityEpoch).
+		Pluck("created_at", &answeredAt).Error; err != nil {
+		return fmt.Errorf("获取答题记录失败: %v", err)
+	}
+	if len(answeredAt) == 0 {
+		return nil
+	}
+
+	counts := make(map[int64]int)
+	for _, at := range answeredAt {
+		if day := activityDay(at, loc); day >= 0 {
+			counts[day]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(counts))
+	for day, count := range counts {
+		fields[activityDate(day)] = count
+	}
+	_, err := r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for day := range counts {
+			pipe.SetBit(ctx, activityKey(userID), day, 1)
+		}
+		pipe.HSet(ctx, activityCountKey(userID), fields)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("补齐每日练习记录失败: %v", err)
+	}
+	return nil
+}
+
+// GetStreak 获取用户截至 now 的连续练习天数
+func (r *Redis) GetStreak(ctx context.Context, userID uint, now time.Time) (*Streak, error) {
+	bitmap, err := r.Client.Get(ctx, activityKey(userID)).Bytes()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("获取每日练习记录失败: %v", err)
+	}
+
+	streak := streakFromBitmap(bitmap, activityDay(now, r.UserLocation(ctx, userID)))
+	return &streak, nil
+}
+
+// GetActivityCalendar 获取用户截至 now 最近 days 天每天的答题数，按日期从早到晚排列
+func (r *Redis) GetActivityCalendar(ctx context.Context, userID uint, now time.Time, days int) ([]ActivityDay, error) {
+	today := activityDay(now, r.UserLocation(ctx, userID))
+	first := max(today-int64(days)+1, 0)
+
+	var dates []string
+	for day := first; day <= today; day++ {
+		dates = append(dates, activityDate(day))
+	}
+	if len(dates) == 0 {
+		return []ActivityDay{}, nil
+	}
+
+	counts, err := r.Client.HMGet(ctx, activityCountKey(userID), dates...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("获取每日答题数失败: %v", err)
+	}
+
+	calendar := make([]ActivityDay, len(dates))
+	for i, date := range dates {
+		calendar[i].Date = date
+		if value, ok := counts[i].(string); ok {
+			calendar[i].Count, _ = strconv.Atoi(value)
+		}
+	}
+	return calendar, nil
+}
@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+// setBits 模拟 Redis SETBIT，返回设置了指定位的 bitmap
+func setBits(offsets ...int64) []byte {
+	var bitmap []byte
+	for _, offset := range offsets {
+		for int64(len(bitmap)) <= offset/8 {
+			bitmap = append(bitmap, 0)
+		}
+		bitmap[offset/8] |= 0x80 >> (offset % 8)
+	}
+	return bitmap
+}
+
+func TestActivityDay_TimeZone(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	losAngeles := time.FixedZone("UTC-7", -7*3600)
+
+	// 同一时刻在不同时区属于不同的日期
+	ts := time.Date(2025, 6, 15, 20, 0, 0, 0, time.UTC)
+	if got, want := activityDate(activityDay(ts, shanghai)), "2025-06-16"; got != want {
+		t.Errorf("东八区日期应为 %s, 实际 %s", want, got)
+	}
+	if got, want := activityDate(activityDay(ts, losAngeles)), "2025-06-15"; got != want {
+		t.Errorf("西七区日期应为 %s, 实际 %s", want, got)
+	}
+	if got := activityDay(activityEpoch, time.UTC); got != 0 {
+		t.Errorf("基准日期应为第 0 位, 实际 %d", got)
+	}
+}
+
+func TestStreakFromBitmap(t *testing.T) {
+	tests := []struct {
+		name   string
+		bitmap []byte
+		today  int64
+		want   Streak
+	}{
+		{"没有记录", nil, 100, Streak{}},
+		{"今天已练习", setBits(97, 98, 99, 100), 100, Streak{Current: 4, Longest: 4, ActiveToday: true}},
+		{"今天还没练习不中断", setBits(97, 98, 99), 100, Streak{Current: 3, Longest: 3}},
+		{"昨天没练习已中断", setBits(97, 98), 100, Streak{Current: 0, Longest: 2}},
+		{"最长连续在过去", setBits(3, 4, 5, 6, 7, 8, 9, 10, 11, 50, 99, 100), 100, Streak{Current: 2, Longest: 9, ActiveToday: true}},
+	}
+	for _, tt := range tests {
+		if got := streakFromBitmap(tt.bitmap, tt.today); got != tt.want {
+			t.Errorf("%s: 期望 %+v, 实际 %+v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// AnswerCountKeyPrefix 用户累计答题数的 key 前缀：answer_count:<用户ID>
+	AnswerCountKeyPrefix = "answer_count:"
+	// AnswerCountTTL 累计答题数缓存的过期时间，每次答题后顺延，过期后从 MySQL 重新统计
+	AnswerCountTTL = 30 * 24 * time.Hour
+)
+
+// answerCountKey 返回用户的累计答题数 key
+func answerCountKey(userID uint) string {
+	return fmt.Sprintf("%s%d", AnswerCountKeyPrefix, userID)
+}
+
+// IncrAnswerCount 用户答了一道题后累加其累计答题数并返回累加后的值
+// 缓存不存在时从 MySQL 统计计入练习统计的答题记录（包括刚保存的这一条），结果只用于判断是否需要检查答题数成就，允许略有偏差
+func (r *Redis) IncrAnswerCount(ctx context.Context, userID uint) (int64, error) {
+	key := answerCountKey(userID)
+	count, err := r.Client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("累加答题数失败: %v", err)
+	}
+
+	if count == 1 {
+		if err := database.DB.Model(&model.HistoryRecord{}).Scopes(model.CountedAnswers).
+			Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			r.Client.Del(ctx, key)
+			return 0, fmt.Errorf("统计答题数失败: %v", err)
+		}
+		if err := r.Client.Set(ctx, key, count, AnswerCountTTL).Err(); err != nil {
+			return 0, fmt.Errorf("缓存答题数失败: %v", err)
+		}
+		return count, nil
+	}
+
+	if err := r.Client.Expire(ctx, key, AnswerCountTTL).Err(); err != nil {
+		fmt.Printf("设置答题数缓存过期时间失败: %v\n", err)
+	}
+	return count, nil
+}
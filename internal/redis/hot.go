@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"math"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// PeriodHot 热度榜：热度 = Σ 每道题的得分 × 时间衰减因子
+	PeriodHot = "hot"
+	// DefaultHotHalfLife 默认半衰期，可通过环境变量 HOT_SCORE_HALF_LIFE 配置（如 12h）
+	DefaultHotHalfLife = 24 * time.Hour
+)
+
+// hotEpoch 前向衰减的基准时间，修改后需要重建排行榜
+var hotEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// logAddScript 在 log2 空间中累加热度：score = log2(2^score + 2^ARGV[1])
+var logAddScript = redis.NewScript(`
+local x = tonumber(ARGV[1])
+local current = redis.call("ZSCORE", KEYS[1], ARGV[2])
+if current then
+	local c = tonumber(current)
+	local hi, lo = math.max(c, x), math.min(c, x)
+	x = hi + math.log(1 + 2 ^ (lo - hi)) / math.log(2)
+end
+redis.call("ZADD", KEYS[1], x, ARGV[2])
+return tostring(x)
+`)
+
+// HotScorer 热度计算，采用前向衰减：
+// 每道题在答题时刻 t 贡献权重 得分×2^((t-epoch)/半衰期)，查询时刻 now 的热度为 Σ权重 / 2^((now-epoch)/半衰期)，
+// 即每道题的贡献每经过一个半衰期减半。权重以 log2 形式存储，避免随时间增长溢出，
+// 且各用户的排序不随查询时刻变化，因此无需定期重算
+type HotScorer struct {
+	HalfLife time.Duration
+}
+
+// hotScorer 返回当前配置的热度计算器
+func hotScorer() HotScorer {
+	halfLife, err := time.ParseDuration(os.Getenv("HOT_SCORE_HALF_LIFE"))
+	if err != nil || halfLife <= 0 {
+		halfLife = DefaultHotHalfLife
+	}
+	return HotScorer{HalfLife: halfLife}
+}
+
+// halfLives 返回 t 距基准时间经过的半衰期数
+func (h HotScorer) halfLives(t time.Time) float64 {
+	return float64(t.Sub(hotEpoch)) / float64(h.HalfLife)
+}
+
+// LogWeight 返回在 answeredAt 答一道得分为 score 的题贡献的 log2 权重，score 必须大于 0
+func (h HotScorer) LogWeight(score float64, answeredAt time.Time) float64 {
+	return math.Log2(score) + h.halfLives(answeredAt)
+}
+
+// Score 将存储的 log2 权重换算为 now 时刻的热度值
+func (h HotScorer) Score(logScore float64, now time.Time) float64 {
+	return math.Exp2(logScore - h.halfLives(now))
+}
+
+// logAdd 返回 log2(2^a + 2^b)，与 logAddScript 的计算一致
+func logAdd(a, b float64) float64 {
+	hi, lo := math.Max(a, b), math.Min(a, b)
+	return hi + math.Log2(1+math.Exp2(lo-hi))
+}
+
+// hotKey 返回指定范围的热度榜 key
+func hotKey(scope RankScope) string {
+	return scope.keyPrefix() + ":" + PeriodHot
+}
@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// fakeClock 测试用的可控时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+func TestHotScorer_Decay(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)}
+	hot := HotScorer{HalfLife: 24 * time.Hour}
+
+	logScore := hot.LogWeight(1, clock.Now())
+	if got := hot.Score(logScore, clock.Now()); !almostEqual(got, 1) {
+		t.Errorf("刚答完的题热度应为 1, 实际 %v", got)
+	}
+
+	clock.Advance(24 * time.Hour)
+	if got := hot.Score(logScore, clock.Now()); !almostEqual(got, 0.5) {
+		t.Errorf("经过一个半衰期热度应为 0.5, 实际 %v", got)
+	}
+
+	clock.Advance(24 * time.Hour)
+	if got := hot.Score(logScore, clock.Now()); !almostEqual(got, 0.25) {
+		t.Errorf("经过两个半衰期热度应为 0.25, 实际 %v", got)
+	}
+}
+
+func TestHotScorer_IncrementalMatchesRebuild(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)}
+	hot := HotScorer{HalfLife: 6 * time.Hour}
+
+	// 模拟实时答题：按时间顺序逐题累加
+	var answeredAt []time.Time
+	var incremental float64
+	for i, gap := range []time.Duration{0, time.Minute, 3 * time.Hour, 30 * time.Second, 20 * time.Hour} {
+		clock.Advance(gap)
+		answeredAt = append(answeredAt, clock.Now())
+		if i == 0 {
+			incremental = hot.LogWeight(1, clock.Now())
+		} else {
+			incremental = logAdd(incremental, hot.LogWeight(1, clock.Now()))
+		}
+	}
+
+	// 模拟重建：以倒序读取记录累加
+	rebuilt := hot.LogWeight(1, answeredAt[len(answeredAt)-1])
+	for i := len(answeredAt) - 2; i >= 0; i-- {
+		rebuilt = logAdd(rebuilt, hot.LogWeight(1, answeredAt[i]))
+	}
+
+	if !almostEqual(incremental, rebuilt) {
+		t.Fatalf("增量结果与重建结果不一致: %v != %v", incremental, rebuilt)
+	}
+
+	// 与按定义直接计算的 Σ 0.5^(经过时间/半衰期) 一致
+	clock.Advance(2 * time.Hour)
+	var want float64
+	for _, ts := range answeredAt {
+		want += math.Pow(0.5, float64(clock.Now().Sub(ts))/float64(hot.HalfLife))
+	}
+	if got := hot.Score(incremental, clock.Now()); !almostEqual(got, want) {
+		t.Errorf("热度值不正确, 期望 %v, 实际 %v", want, got)
+	}
+}
+
+func TestHotScorer_RecentActivityWins(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)}
+	hot := HotScorer{HalfLife: 24 * time.Hour}
+
+	// 用户 A 三天前答了 5 题，用户 B 刚刚答了 1 题
+	a := hot.LogWeight(1, clock.Now())
+	for i := 1; i < 5; i++ {
+		a = logAdd(a, hot.LogWeight(1, clock.Now()))
+	}
+	clock.Advance(72 * time.Hour)
+	b := hot.LogWeight(1, clock.Now())
+
+	if !(b > a) {
+		t.Errorf("近期活跃的用户排名应更靠前: a=%v b=%v", a, b)
+	}
+	if got := hot.Score(a, clock.Now()); !almostEqual(got, 5.0/8) {
+		t.Errorf("三个半衰期后 5 题的热度应为 0.625, 实际 %v", got)
+	}
+}
+
+func TestHotScorerConfig(t *testing.T) {
+	t.Setenv("HOT_SCORE_HALF_LIFE", "12h")
+	if got := hotScorer().HalfLife; got != 12*time.Hour {
+		t.Errorf("半衰期配置不正确, 期望 12h, 实际 %v", got)
+	}
+
+	t.Setenv("HOT_SCORE_HALF_LIFE", "invalid")
+	if got := hotScorer().HalfLife; got != DefaultHotHalfLife {
+		t.Errorf("无效配置应使用默认半衰期, 实际 %v", got)
+	}
+}
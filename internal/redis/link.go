@@ -0,0 +1,65 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 家长关联码 key 前缀
+	ParentLinkCodeKeyPrefix = "parent_link_code:"
+	// 家长关联码有效期
+	ParentLinkCodeTTL = 24 * time.Hour
+
+	// 关联码字符集，去掉了容易混淆的 0/O、1/I
+	linkCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+	linkCodeLength   = 8
+)
+
+// CreateParentLinkCode 为学生生成一次性的家长关联码
+func (r *Redis) CreateParentLinkCode(ctx context.Context, studentID uint) (string, error) {
+	code := make([]byte, linkCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(linkCodeAlphabet))))
+		if err != nil {
+			return "", fmt.Errorf("生成关联码失败: %v", err)
+		}
+		code[i] = linkCodeAlphabet[n.Int64()]
+	}
+
+	ok, err := r.Client.SetNX(ctx, ParentLinkCodeKeyPrefix+string(code), studentID, ParentLinkCodeTTL).Result()
+	if err != nil {
+		return "", fmt.Errorf("保存关联码失败: %v", err)
+	}
+	if !ok {
+		return "", fmt.Errorf("关联码冲突，请重试")
+	}
+	return string(code), nil
+}
+
+// ConsumeParentLinkCode 使用关联码并返回对应的学生ID，关联码使用后立即失效
+func (r *Redis) ConsumeParentLinkCode(ctx context.Context, code string) (uint, error) {
+	key := ParentLinkCodeKeyPrefix + code
+
+	var get *redis.StringCmd
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("关联码无效或已过期")
+	}
+
+	studentID, err := strconv.ParseUint(get.Val(), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("关联码数据无效: %v", err)
+	}
+	return uint(studentID), nil
+}
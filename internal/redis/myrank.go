@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// UserRank 用户在排行榜中的位置
+type UserRank struct {
+	Rank     int     `json:"rank"`      // 名次，从 1 开始；未上榜为 0
+	HotScore float64 `json:"hot_score"` // 当前分数
+	Gap      float64 `json:"gap"`       // 与前一名的分差，第一名为 0
+	Total    int64   `json:"total"`     // 上榜总人数
+	// Neighbours 前后各 radius 名用户（包含自己）
+	Neighbours []RankingItem `json:"neighbours"`
+}
+
+// GetUserRank 获取用户在指定范围和周期排行榜中的名次、分数、与前一名的分差以及前后 radius 名用户
+func (r *Redis) GetUserRank(ctx context.Context, scope RankScope, period string, userID uint, radius int64) (*UserRank, error) {
+	now := time.Now()
+	rankKey, err := r.rankKeyForRead(ctx, scope, period, now)
+	if err != nil {
+		return nil, fmt.Errorf("获取排行榜失败: %v", err)
+	}
+
+	member := fmt.Sprintf("%d", userID)
+	var rankCmd *redis.IntCmd
+	var cardCmd *redis.IntCmd
+	_, err = r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		rankCmd = pipe.ZRevRank(ctx, rankKey, member)
+		cardCmd = pipe.ZCard(ctx, rankKey)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("获取排名失败: %v", err)
+	}
+
+	userRank := &UserRank{Total: cardCmd.Val(), Neighbours: []RankingItem{}}
+	index, err := rankCmd.Result()
+	if errors.Is(err, redis.Nil) {
+		// 未上榜
+		return userRank, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取排名失败: %v", err)
+	}
+
+	// 前后 radius 名，另外至少多取前一名用于计算分差
+	neighbourStart := max(index-radius, 0)
+	start := max(index-max(radius, 1), 0)
+	result, err := r.Client.ZRevRangeWithScores(ctx, rankKey, start, index+radius).Result()
+	if err != nil {
+		return nil, fmt.Errorf("获取排行榜失败: %v", err)
+	}
+	result = displayScores(period, result, now)
+
+	// 排行榜在两次查询之间可能发生变化，以第二次查询的结果为准
+	for i, item := range result {
+		if item.Member.(string) != member {
+			continue
+		}
+		userRank.Rank = int(start) + i + 1
+		userRank.HotScore = item.Score
+		if i > 0 {
+			userRank.Gap = result[i-1].Score - item.Score
+		}
+		break
+	}
+
+	// 去掉为计算分差多取的名次
+	if skip := int(neighbourStart - start); skip <= len(result) {
+		result = result[skip:]
+		start = neighbourStart
+	}
+	userRank.Neighbours = r.buildRankingItems(ctx, result, int(start))
+
+	return userRank, nil
+}
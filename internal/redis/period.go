@@ -0,0 +1,185 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 排行榜周期
+const (
+	// 自然周期，每个周期一个分桶
+	PeriodHourly  = "hourly"
+	PeriodDaily   = "daily"
+	PeriodWeekly  = "weekly" // ISO 周，周一开始
+	PeriodMonthly = "monthly"
+	PeriodAll     = "all"
+	// 滚动窗口，由多个分桶合并而成
+	PeriodLast24h = "24h"
+	PeriodLast7d  = "7d"
+)
+
+// rollingCacheTTL 滚动窗口合并结果的缓存时间
+const rollingCacheTTL = time.Minute
+
+// bucketPeriods 需要分桶写入的周期
+var bucketPeriods = []string{PeriodHourly, PeriodDaily, PeriodWeekly, PeriodMonthly}
+
+// bucketRetention 分桶在周期结束后的保留时间，需覆盖对应的滚动窗口
+var bucketRetention = map[string]time.Duration{
+	PeriodHourly:  24 * time.Hour,
+	PeriodDaily:   7 * 24 * time.Hour,
+	PeriodWeekly:  28 * 24 * time.Hour,
+	PeriodMonthly: 365 * 24 * time.Hour,
+}
+
+// rollingWindow 滚动窗口：由最近 count 个 period 分桶合并
+type rollingWindow struct {
+	period string
+	count  int
+}
+
+var rollingWindows = map[string]rollingWindow{
+	PeriodLast24h: {period: PeriodHourly, count: 24},
+	PeriodLast7d:  {period: PeriodDaily, count: 7},
+}
+
+// ValidRankPeriod 判断排行榜周期是否合法
+func ValidRankPeriod(period string) bool {
+	if _, ok := bucketRetention[period]; ok {
+		return true
+	}
+	if _, ok := rollingWindows[period]; ok {
+		return true
+	}
+	return period == PeriodAll || period == PeriodHot || period == PeriodSeason
+}
+
+// bucketStart 返回 t 所在分桶的开始时间（按本地时区）
+func bucketStart(period string, t time.Time) time.Time {
+	y, m, d := t.Date()
+	switch period {
+	case PeriodHourly:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location())
+	case PeriodDaily:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	case PeriodWeekly:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// bucketEnd 返回 t 所在分桶的结束时间
+func bucketEnd(period string, t time.Time) time.Time {
+	start := bucketStart(period, t)
+	switch period {
+	case PeriodHourly:
+		return start.Add(time.Hour)
+	case PeriodDaily:
+		return start.AddDate(0, 0, 1)
+	case PeriodWeekly:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// bucketID 返回 t 所在分桶的标识，如 2025061314、20250613、2025W24、202506
+func bucketID(period string, t time.Time) string {
+	switch period {
+	case PeriodHourly:
+		return t.Format("2006010215")
+	case PeriodDaily:
+		return t.Format("20060102")
+	case PeriodWeekly:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%dW%02d", year, week)
+	default:
+		return t.Format("200601")
+	}
+}
+
+// bucketExpireAt 返回 t 所在分桶的过期时间
+func bucketExpireAt(period string, t time.Time) time.Time {
+	return bucketEnd(period, t).Add(bucketRetention[period])
+}
+
+// bucketKey 返回指定范围、周期下 t 所在分桶的 key，如 rank:class:3:daily:20250613
+func bucketKey(scope RankScope, period string, t time.Time) string {
+	return fmt.Sprintf("%s:%s:%s", scope.keyPrefix(), period, bucketID(period, t))
+}
+
+// allTimeKey 返回指定范围的总榜 key
+func allTimeKey(scope RankScope) string {
+	return fmt.Sprintf("%s:%s", scope.keyPrefix(), PeriodAll)
+}
+
+// rankKeyForRead 返回读取排行榜时使用的 key，滚动窗口会先合并分桶
+func (r *Redis) rankKeyForRead(ctx context.Context, scope RankScope, period string, now time.Time) (string, error) {
+	switch period {
+	case PeriodAll:
+		return allTimeKey(scope), nil
+	case PeriodHot:
+		return hotKey(scope), nil
+	case PeriodSeason:
+		season, err := r.CurrentSeason(now)
+		if err != nil {
+			return "", err
+		}
+		return seasonKey(scope, season.ID), nil
+	}
+
+	window, ok := rollingWindows[period]
+	if !ok {
+		return bucketKey(scope, period, now), nil
+	}
+
+	key := fmt.Sprintf("%s:%s", scope.keyPrefix(), period)
+	exists, err := r.Client.Exists(ctx, key).Result()
+	if err != nil {
+		return "", err
+	}
+	if exists > 0 {
+		return key, nil
+	}
+
+	// 合并最近的分桶，结果短暂缓存，避免每次请求都重新计算
+	keys := make([]string, 0, window.count)
+	t := bucketStart(window.period, now)
+	for i := 0; i < window.count; i++ {
+		keys = append(keys, bucketKey(scope, window.period, t))
+		t = bucketStart(window.period, t.Add(-time.Second))
+	}
+
+	_, err = r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZUnionStore(ctx, key, &redis.ZStore{Keys: keys, Aggregate: "SUM"})
+		pipe.Expire(ctx, key, rollingCacheTTL)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// rankBucket 排行榜分桶，expireAt 为零值表示永不过期
+type rankBucket struct {
+	key      string
+	expireAt time.Time
+}
+
+// rankBuckets 返回 t 时刻的答题需要计入的所有分桶（各周期分桶和总榜）
+func rankBuckets(scope RankScope, t time.Time) []rankBucket {
+	buckets := make([]rankBucket, 0, len(bucketPeriods)+1)
+	for _, period := range bucketPeriods {
+		buckets = append(buckets, rankBucket{
+			key:      bucketKey(scope, period, t),
+			expireAt: bucketExpireAt(period, t),
+		})
+	}
+	return append(buckets, rankBucket{key: allTimeKey(scope)})
+}
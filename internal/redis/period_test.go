@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketID(t *testing.T) {
+	// 2024-12-30 是周一，属于 ISO 2025 年第 1 周
+	ts := time.Date(2024, 12, 31, 14, 30, 0, 0, time.UTC)
+
+	tests := map[string]string{
+		PeriodHourly:  "2024123114",
+		PeriodDaily:   "20241231",
+		PeriodWeekly:  "2025W01",
+		PeriodMonthly: "202412",
+	}
+	for period, want := range tests {
+		if got := bucketID(period, ts); got != want {
+			t.Errorf("%s 分桶标识不正确, 期望 %s, 实际 %s", period, want, got)
+		}
+	}
+}
+
+func TestBucketStartAndEnd(t *testing.T) {
+	ts := time.Date(2025, 6, 15, 9, 45, 0, 0, time.UTC) // 周日
+
+	tests := []struct {
+		period     string
+		start, end time.Time
+	}{
+		{PeriodHourly, time.Date(2025, 6, 15, 9, 0, 0, 0, time.UTC), time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)},
+		{PeriodDaily, time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC)},
+		{PeriodWeekly, time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC)},
+		{PeriodMonthly, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := bucketStart(tt.period, ts); !got.Equal(tt.start) {
+			t.Errorf("%s 开始时间不正确, 期望 %v, 实际 %v", tt.period, tt.start, got)
+		}
+		if got := bucketEnd(tt.period, ts); !got.Equal(tt.end) {
+			t.Errorf("%s 结束时间不正确, 期望 %v, 实际 %v", tt.period, tt.end, got)
+		}
+	}
+}
+
+func TestRankBuckets(t *testing.T) {
+	ts := time.Date(2025, 6, 15, 9, 45, 0, 0, time.UTC)
+	scope := RankScope{Scope: RankScopeClass, ID: 3}
+
+	buckets := rankBuckets(scope, ts)
+	if len(buckets) != len(bucketPeriods)+1 {
+		t.Fatalf("分桶数量不正确, 期望 %d, 实际 %d", len(bucketPeriods)+1, len(buckets))
+	}
+	if buckets[1].key != "rank:class:3:daily:20250615" {
+		t.Errorf("日榜分桶 key 不正确: %s", buckets[1].key)
+	}
+	last := buckets[len(buckets)-1]
+	if last.key != "rank:class:3:all" || !last.expireAt.IsZero() {
+		t.Errorf("总榜分桶不正确: %+v", last)
+	}
+}
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 限流 key 前缀
+	RateLimitKeyPrefix = "ratelimit:"
+)
+
+// tokenBucketScript 令牌桶脚本
+// KEYS[1] 桶的 key
+// ARGV[1] 每秒补充的令牌数，ARGV[2] 桶容量，ARGV[3] 当前时间（毫秒）
+// 返回 {是否放行, 需要等待的毫秒数}
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+	tokens = burst
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(burst, tokens + elapsed * rate / 1000)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tokens, "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}
+`)
+
+// TakeToken 从令牌桶中取一个令牌，返回是否放行以及需要等待的时间
+func (r *Redis) TakeToken(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error) {
+	if rate <= 0 || burst <= 0 {
+		return false, 0, fmt.Errorf("无效的限流参数: rate=%v, burst=%d", rate, burst)
+	}
+
+	result, err := tokenBucketScript.Run(ctx, r.Client,
+		[]string{RateLimitKeyPrefix + key},
+		rate, burst, time.Now().UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("执行限流脚本失败: %v", err)
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("限流脚本返回值无效: %v", result)
+	}
+
+	wait := time.Duration(math.Max(0, float64(result[1]))) * time.Millisecond
+	return result[0] == 1, wait, nil
+}
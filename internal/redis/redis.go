@@ -3,9 +3,9 @@ package redis
 import (
 	"calculator/internal/database"
 	"calculator/internal/model"
+	"calculator/internal/scoring"
 	"context"
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,18 +14,16 @@ import (
 const (
 	// Redis key 前缀
 	QuestionKeyPrefix = "question:"
-	HourlyRankKey     = "rank:hourly"
-	DailyRankKey      = "rank:daily"
-	// 时间衰减因子（24小时）
-	TimeDecayFactor = 24 * time.Hour
+	RankKeyPrefix     = "rank:"
 )
 
 // Redis Redis客户端封装
 type Redis struct {
-	Client *redis.Client
+	Client  *redis.Client
+	seasons seasonCache
 }
 
-// NewRedis 创建新的Redis客户端
+// NewRedis 创建新的Redis客户端，不启动后台任务
 func NewRedis() *Redis {
 	client := &Redis{
 		Client: redis.NewClient(&redis.Options{
@@ -39,12 +37,19 @@ func NewRedis() *Redis {
 		}),
 	}
 
-	// 启动定期更新排行榜的goroutine
-	go client.startPeriodicRankingUpdate()
-
 	return client
 }
 
+// StartBackgroundJobs 启动排行榜定期重建、赛季归档和每日排名存档，每个进程只应调用一次
+func (r *Redis) StartBackgroundJobs() {
+	// 启动定期更新排行榜的goroutine
+	go r.startPeriodicRankingUpdate()
+	// 启动赛季归档的goroutine
+	go r.startSeasonRollover()
+	// 启动每日排名存档的goroutine
+	go r.startRankSnapshots()
+}
+
 // startPeriodicRankingUpdate 启动定期更新排行榜的goroutine
 func (r *Redis) startPeriodicRankingUpdate() {
 	ticker := time.NewTicker(5 * time.Hour)
@@ -57,126 +62,247 @@ func (r *Redis) startPeriodicRankingUpdate() {
 	}
 }
 
-// InitRankingData 从MySQL初始化排行榜数据
+// InitRankingData 从MySQL重建排行榜数据
+// 新数据先写入临时 key，再用 RENAME 原子替换，重建期间排行榜仍可正常读取
 func (r *Redis) InitRankingData() error {
 	ctx := context.Background()
+	now := time.Now()
 
-	// 清空现有的排行榜数据
-	if err := r.Client.Del(ctx, HourlyRankKey, DailyRankKey).Err(); err != nil {
-		return fmt.Errorf("清空排行榜数据失败: %v", err)
+	// 先归档已结束的赛季，避免其排行榜在归档前被当作过期数据删除
+	if err := r.ArchiveEndedSeasons(ctx); err != nil {
+		return err
 	}
 
-	// 从MySQL获取所有用户的历史记录
+	// 重建前已存在的排行榜，重建后不再需要的会被删除
+	oldKeys, err := r.scanRankKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("获取排行榜数据失败: %v", err)
+	}
+
+	// 从MySQL获取所有用户的历史记录（超时的答案、考试记录和客户端上报的记录不计热度）
+	// 按用户和答题时间排序，得分规则需要按顺序累积计分状态
 	var historyRecords []model.HistoryRecord
-	if err := database.DB.Find(&historyRecords).Error; err != nil {
+	if err := database.DB.Scopes(model.CountedAnswers).Where("timed_out = ?", false).
+		Order("user_id, created_at, id").Find(&historyRecords).Error; err != nil {
 		return fmt.Errorf("获取历史记录失败: %v", err)
 	}
+	historyRecords = dropReplayedAnswers(historyRecords)
+
+	// 学生所在的班级和学校
+	scopes, err := allRankScopes()
+	if err != nil {
+		return err
+	}
 
-	// 按用户ID分组统计
-	userScores := make(map[uint]float64)
-	for _, record := range historyRecords {
-		// 计算基础分数（答对2分，答错1分）
-		score := 1.0
-		if record.IsCorrect {
-			score = 2.0
+	// 当前赛季
+	season, err := r.CurrentSeason(now)
+	if err != nil {
+		return err
+	}
+
+	// 按分桶统计每个用户的分数，已过期的分桶直接跳过
+	// 得分规则和热度榜的 log2 累加都与 UpdateUserHotScore 的增量计算一致
+	policy := scoring.Current()
+	states := make(map[uint]*scoring.State)
+	hot := hotScorer()
+	bucketScores := make(map[string]map[string]float64)
+	bucketExpires := make(map[string]time.Time)
+	for i := range historyRecords {
+		record := &historyRecords[i]
+		member := fmt.Sprintf("%d", record.UserID)
+
+		state := states[record.UserID]
+		if state == nil {
+			state = &scoring.State{}
+			states[record.UserID] = state
+		}
+		score := policy.Score(state, scoringAnswer(record))
+		if score <= 0 {
+			continue
 		}
+		logWeight := hot.LogWeight(score, record.CreatedAt)
 
-		// 应用时间衰减
-		timeDecay := math.Exp(-float64(record.CreatedAt.Unix()) / float64(TimeDecayFactor.Seconds()))
-		finalScore := score * timeDecay
+		userScopes := append([]RankScope{GlobalRankScope}, scopes[record.UserID]...)
+		for _, scope := range userScopes {
+			key := hotKey(scope)
+			if bucketScores[key] == nil {
+				bucketScores[key] = make(map[string]float64)
+			}
+			if current, ok := bucketScores[key][member]; ok {
+				bucketScores[key][member] = logAdd(current, logWeight)
+			} else {
+				bucketScores[key][member] = logWeight
+			}
 
-		// 累加用户分数
-		userScores[record.UserID] += finalScore
+			buckets := rankBuckets(scope, record.CreatedAt)
+			if !record.CreatedAt.Before(season.StartsAt) && record.CreatedAt.Before(season.EndsAt) {
+				buckets = append(buckets, rankBucket{key: seasonKey(scope, season.ID)})
+			}
+			for _, bucket := range buckets {
+				if !bucket.expireAt.IsZero() && !bucket.expireAt.After(now) {
+					continue
+				}
+				if bucketScores[bucket.key] == nil {
+					bucketScores[bucket.key] = make(map[string]float64)
+				}
+				bucketScores[bucket.key][member] += score
+				bucketExpires[bucket.key] = bucket.expireAt
+			}
+		}
 	}
 
-	// 将用户分数写入Redis
-	for userID, score := range userScores {
-		// 更新小时榜
-		if err := r.Client.ZAdd(ctx, HourlyRankKey, redis.Z{
-			Score:  score,
-			Member: fmt.Sprintf("%d", userID),
-		}).Err(); err != nil {
-			return fmt.Errorf("更新小时榜失败: %v", err)
+	// 写入临时 key
+	pipe := r.Client.Pipeline()
+	for key, scores := range bucketScores {
+		tmpKey := key + ":rebuild"
+		members := make([]redis.Z, 0, len(scores))
+		for member, score := range scores {
+			members = append(members, redis.Z{Score: score, Member: member})
+		}
+		pipe.Del(ctx, tmpKey)
+		pipe.ZAdd(ctx, tmpKey, members...)
+		if expireAt := bucketExpires[key]; !expireAt.IsZero() {
+			pipe.ExpireAt(ctx, tmpKey, expireAt)
 		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("写入排行榜数据失败: %v", err)
+	}
 
-		// 更新日榜
-		if err := r.Client.ZAdd(ctx, DailyRankKey, redis.Z{
-			Score:  score,
-			Member: fmt.Sprintf("%d", userID),
-		}).Err(); err != nil {
-			return fmt.Errorf("更新日榜失败: %v", err)
+	// 替换为新数据，并删除没有数据的旧排行榜
+	pipe = r.Client.Pipeline()
+	for key := range bucketScores {
+		pipe.Rename(ctx, key+":rebuild", key)
+	}
+	for _, key := range oldKeys {
+		if _, ok := bucketScores[key]; !ok {
+			pipe.Del(ctx, key)
 		}
 	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("更新排行榜数据失败: %v", err)
+	}
 
-	return nil
+	// 保存计分状态，之后的实时更新从重建后的状态继续累积
+	return r.saveScoreStates(ctx, policy, states, now)
 }
 
-// UpdateUserHotScore 更新用户热度值
-func (r *Redis) UpdateUserHotScore(ctx context.Context, userID uint, isCorrect bool) error {
-	// 更新所有排行榜
-	rankKeys := []string{HourlyRankKey, DailyRankKey}
-	for _, key := range rankKeys {
-		// 基础分数增量
-		scoreIncrement := 50.0 // 每次答题基础加分
-
-		// 答对额外加分
-		if isCorrect {
-			scoreIncrement += 100.0 // 答对额外加100分
-		}
+// UpdateUserHotScore 按得分规则计算一次答题的得分，更新用户热度值
+// 分数同时计入全站、用户所在班级和学校的各周期分桶、总榜以及热度榜
+func (r *Redis) UpdateUserHotScore(ctx context.Context, record *model.HistoryRecord) error {
+	now := record.CreatedAt
+	userID := record.UserID
+	member := fmt.Sprintf("%d", userID)
+
+	score, err := r.nextAnswerScore(ctx, scoring.Current(), record)
+	if err != nil {
+		return err
+	}
+	if score <= 0 {
+		return nil
+	}
+	logWeight := hotScorer().LogWeight(score, now)
 
-		// 使用 ZINCRBY 增加分数
-		err := r.Client.ZIncrBy(ctx, key, scoreIncrement, fmt.Sprintf("%d", userID)).Err()
-		if err != nil {
-			return fmt.Errorf("更新排行榜 %s 失败: %v", key, err)
+	scopes, scopeErr := userRankScopes(userID)
+	scopes = append([]RankScope{GlobalRankScope}, scopes...)
+
+	// 赛季查询失败时仍然更新其他排行榜
+	season, seasonErr := r.CurrentSeason(now)
+
+	_, err = r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, scope := range scopes {
+			for _, bucket := range rankBuckets(scope, now) {
+				pipe.ZIncrBy(ctx, bucket.key, score, member)
+				if !bucket.expireAt.IsZero() {
+					pipe.ExpireAt(ctx, bucket.key, bucket.expireAt)
+				}
+			}
+			logAddScript.Eval(ctx, pipe, []string{hotKey(scope)}, logWeight, member)
+			if season != nil {
+				pipe.ZIncrBy(ctx, seasonKey(scope, season.ID), score, member)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("更新排行榜失败: %v", err)
+	}
+
+	// 通知各实例推送最新排行榜，失败不影响分数更新
+	if err := r.publishRankUpdate(ctx, scopes); err != nil {
+		fmt.Printf("通知排行榜变化失败: %v\n", err)
 	}
 
-	return nil
+	// 查询班级失败时仍然更新全局排行榜
+	if scopeErr != nil {
+		return scopeErr
+	}
+	return seasonErr
 }
 
-// GetHotRanking 获取热度排行榜
-func (r *Redis) GetHotRanking(ctx context.Context, rankType string, limit int64) ([]RankingItem, error) {
-	var rankKey string
-	switch rankType {
-	case "hourly":
-		rankKey = HourlyRankKey
-	default:
-		rankKey = DailyRankKey
+// GetHotRanking 获取指定范围和周期的热度排行榜，offset、limit 用于分页，同时返回上榜总人数
+func (r *Redis) GetHotRanking(ctx context.Context, scope RankScope, period string, offset, limit int64) ([]RankingItem, int64, error) {
+	now := time.Now()
+	rankKey, err := r.rankKeyForRead(ctx, scope, period, now)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取排行榜失败: %v", err)
 	}
 
 	// 获取排行榜数据
-	result, err := r.Client.ZRevRangeWithScores(ctx, rankKey, 0, limit-1).Result()
+	var rangeCmd *redis.ZSliceCmd
+	var cardCmd *redis.IntCmd
+	_, err = r.Client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		rangeCmd = pipe.ZRevRangeWithScores(ctx, rankKey, offset, offset+limit-1)
+		cardCmd = pipe.ZCard(ctx, rankKey)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("获取排行榜失败: %v", err)
+		return nil, 0, fmt.Errorf("获取排行榜失败: %v", err)
 	}
 
-	// 构建返回数据
+	result := displayScores(period, rangeCmd.Val(), now)
+	return r.buildRankingItems(ctx, result, int(offset)), cardCmd.Val(), nil
+}
+
+// displayScores 将有序集合中的分数转换为展示值
+// 热度榜存储的是 log2 权重，换算为当前的热度值
+func displayScores(period string, result []redis.Z, now time.Time) []redis.Z {
+	if period == PeriodHot {
+		hot := hotScorer()
+		for i := range result {
+			result[i].Score = hot.Score(result[i].Score, now)
+		}
+	}
+	return result
+}
+
+// buildRankingItems 将有序集合结果转换为排行榜项目，offset 为首项的排名偏移
+func (r *Redis) buildRankingItems(ctx context.Context, result []redis.Z, offset int) []RankingItem {
+	userIDs := make([]string, 0, len(result))
+	for _, item := range result {
+		userIDs = append(userIDs, item.Member.(string))
+	}
+	names := r.resolveUsernames(ctx, userIDs)
+
 	rankings := make([]RankingItem, 0, len(result))
 	for i, item := range result {
-		// 从用户ID获取用户名
 		userID := item.Member.(string)
 
-		var user model.User
-		if err := database.DB.First(&user, userID).Error; err != nil {
-			// 如果找不到用户，使用默认名称
-			rankings = append(rankings, RankingItem{
-				Rank:     i + 1,
-				UserID:   userID,
-				Username: fmt.Sprintf("用户%s", userID),
-				HotScore: item.Score,
-			})
-			continue
+		// 如果找不到用户，使用默认名称
+		username, ok := names[userID]
+		if !ok {
+			username = fmt.Sprintf("用户%s", userID)
 		}
 
 		rankings = append(rankings, RankingItem{
-			Rank:     i + 1,
+			Rank:     offset + i + 1,
 			UserID:   userID,
-			Username: user.Username,
+			Username: username,
 			HotScore: item.Score,
 		})
 	}
 
-	return rankings, nil
+	return rankings
 }
 
 // RankingItem 排行榜项目
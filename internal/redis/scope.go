@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"context"
+	"fmt"
+)
+
+// 排行榜范围
+const (
+	RankScopeGlobal = "global"
+	RankScopeClass  = "class"
+	RankScopeSchool = "school"
+)
+
+// RankScope 班级或学校范围的排行榜
+type RankScope struct {
+	Scope string `json:"scope"`
+	ID    uint   `json:"id,omitempty"`
+}
+
+// GlobalRankScope 全站排行榜
+var GlobalRankScope = RankScope{Scope: RankScopeGlobal}
+
+// keyPrefix 返回范围对应的排行榜 key 前缀，如 rank:global、rank:class:3
+func (s RankScope) keyPrefix() string {
+	if s.Scope == RankScopeGlobal {
+		return RankKeyPrefix + RankScopeGlobal
+	}
+	return fmt.Sprintf("%s%s:%d", RankKeyPrefix, s.Scope, s.ID)
+}
+
+// enrollmentScope 学生所在班级及班级所属学校
+type enrollmentScope struct {
+	StudentID uint
+	ClassID   uint
+	SchoolID  *uint
+}
+
+// appendScopes 将班级和学校范围去重后追加到 scopes
+func appendScopes(scopes []RankScope, row enrollmentScope) []RankScope {
+	candidates := []RankScope{{Scope: RankScopeClass, ID: row.ClassID}}
+	if row.SchoolID != nil {
+		candidates = append(candidates, RankScope{Scope: RankScopeSchool, ID: *row.SchoolID})
+	}
+
+	for _, candidate := range candidates {
+		exists := false
+		for _, scope := range scopes {
+			if scope == candidate {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			scopes = append(scopes, candidate)
+		}
+	}
+	return scopes
+}
+
+// queryEnrollmentScopes 查询学生的班级和学校，studentID 为 0 时查询所有学生
+func queryEnrollmentScopes(studentID uint) ([]enrollmentScope, error) {
+	query := database.DB.Model(&model.Enrollment{}).
+		Select("enrollments.student_id, enrollments.class_id, classes.school_id").
+		Joins("JOIN classes ON classes.id = enrollments.class_id")
+	if studentID != 0 {
+		query = query.Where("enrollments.student_id = ?", studentID)
+	}
+
+	var rows []enrollmentScope
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("获取班级信息失败: %v", err)
+	}
+	return rows, nil
+}
+
+// userRankScopes 返回用户参与的班级和学校排行榜范围
+func userRankScopes(userID uint) ([]RankScope, error) {
+	rows, err := queryEnrollmentScopes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var scopes []RankScope
+	for _, row := range rows {
+		scopes = appendScopes(scopes, row)
+	}
+	return scopes, nil
+}
+
+// allRankScopes 返回所有学生参与的班级和学校排行榜范围，用于重建排行榜
+func allRankScopes() (map[uint][]RankScope, error) {
+	rows, err := queryEnrollmentScopes(0)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make(map[uint][]RankScope)
+	for _, row := range rows {
+		scopes[row.StudentID] = appendScopes(scopes[row.StudentID], row)
+	}
+	return scopes, nil
+}
+
+// scanRankKeys 返回所有范围的排行榜 key
+func (r *Redis) scanRankKeys(ctx context.Context) ([]string, error) {
+	var keys []string
+	for _, scope := range []string{RankScopeGlobal, RankScopeClass, RankScopeSchool} {
+		iter := r.Client.Scan(ctx, 0, RankKeyPrefix+scope+":*", 100).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// RemoveFromClassRanking 学生移出班级时，将其从该班级的所有排行榜中删除
+// 学校排行榜在下次重建时更新，避免学生同校的其他班级被误删
+func (r *Redis) RemoveFromClassRanking(ctx context.Context, classID, userID uint) error {
+	member := fmt.Sprintf("%d", userID)
+	scope := RankScope{Scope: RankScopeClass, ID: classID}
+	iter := r.Client.Scan(ctx, 0, scope.keyPrefix()+":*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := r.Client.ZRem(ctx, iter.Val(), member).Err(); err != nil {
+			return fmt.Errorf("更新班级排行榜失败: %v", err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("更新班级排行榜失败: %v", err)
+	}
+	return nil
+}
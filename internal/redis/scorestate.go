@@ -0,0 +1,118 @@
+package redis
+
+import (
+	"calculator/internal/model"
+	"calculator/internal/scoring"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ScoreStateKeyPrefix 用户计分状态（当天简单题数、连对题数等）的 key 前缀
+const ScoreStateKeyPrefix = "score_state:"
+
+// scoreStateRetries 计分状态被并发修改时的重试次数
+const scoreStateRetries = 3
+
+// scoreStateKey 返回用户计分状态的 key
+func scoreStateKey(userID uint) string {
+	return fmt.Sprintf("%s%d", ScoreStateKeyPrefix, userID)
+}
+
+// scoringAnswer 将历史记录转换为计分使用的答题
+func scoringAnswer(record *model.HistoryRecord) scoring.Answer {
+	return scoring.Answer{
+		Difficulty: record.Difficulty,
+		Correct:    record.IsCorrect,
+		TimeSpent:  record.TimeSpent,
+		At:         record.CreatedAt,
+	}
+}
+
+// dropReplayedAnswers 去掉同一用户重复提交同一道题的记录，只保留第一次作答
+// 早期普通练习的题目可以重复提交，重建排行榜时这些重复记录不计分，也不参与连对等计分状态
+func dropReplayedAnswers(records []model.HistoryRecord) []model.HistoryRecord {
+	type answered struct {
+		userID     uint
+		questionID string
+	}
+	seen := make(map[answered]bool, len(records))
+	kept := records[:0]
+	for _, record := range records {
+		key := answered{record.UserID, record.QuestionID}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, record)
+	}
+	return kept
+}
+
+// nextAnswerScore 按得分规则计算一次答题的得分，并更新保存在 Redis 中的计分状态
+// 使用 WATCH 保证同一用户并发答题时计分状态不会被覆盖
+func (r *Redis) nextAnswerScore(ctx context.Context, policy scoring.Policy, record *model.HistoryRecord) (float64, error) {
+	key := scoreStateKey(record.UserID)
+
+	var score float64
+	update := func(tx *redis.Tx) error {
+		var state scoring.State
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &state); err != nil {
+				// 状态损坏时重新开始计算
+				state = scoring.State{}
+			}
+		}
+
+		score = policy.Score(&state, scoringAnswer(record))
+
+		payload, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, payload, policy.StateTTL())
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < scoreStateRetries; i++ {
+		err := r.Client.Watch(ctx, update, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			if err != nil {
+				return 0, fmt.Errorf("更新计分状态失败: %v", err)
+			}
+			return score, nil
+		}
+	}
+	return 0, fmt.Errorf("更新计分状态失败: %v", redis.TxFailedErr)
+}
+
+// saveScoreStates 重建排行榜后保存各用户的计分状态，已失效的状态不保存
+func (r *Redis) saveScoreStates(ctx context.Context, policy scoring.Policy, states map[uint]*scoring.State, now time.Time) error {
+	pipe := r.Client.Pipeline()
+	for userID, state := range states {
+		ttl := policy.StateTTL() - now.Sub(time.Unix(state.LastAt, 0))
+		if ttl <= 0 {
+			continue
+		}
+		payload, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, scoreStateKey(userID), payload, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("保存计分状态失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package redis
+
+import (
+	"calculator/internal/model"
+	"testing"
+)
+
+func TestDropReplayedAnswers(t *testing.T) {
+	records := []model.HistoryRecord{
+		{ID: 1, UserID: 1, QuestionID: "100"},
+		{ID: 2, UserID: 1, QuestionID: "100"}, // 重复提交
+		{ID: 3, UserID: 1, QuestionID: "101"},
+		{ID: 4, UserID: 2, QuestionID: "100"}, // 其他用户的同一题号不算重复
+		{ID: 5, UserID: 1, QuestionID: "100"}, // 重复提交
+	}
+
+	kept := dropReplayedAnswers(records)
+	var ids []uint
+	for _, record := range kept {
+		ids = append(ids, record.ID)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 3 || ids[2] != 4 {
+		t.Errorf("应只保留每道题的第一次作答, 实际 %v", ids)
+	}
+}
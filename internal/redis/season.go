@@ -0,0 +1,212 @@
+package redis
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// PeriodSeason 当前赛季排行榜
+	PeriodSeason = "season"
+	// SeasonStandingLimit 赛季结束时每个排行榜保存的名次数
+	SeasonStandingLimit = 100
+	// seasonRolloverInterval 检查赛季是否结束的间隔
+	seasonRolloverInterval = time.Minute
+	// seasonCacheTTL 当前赛季的缓存时间，管理员调整赛季后最多延迟这么久生效
+	seasonCacheTTL = time.Minute
+)
+
+// seasonCache 当前赛季缓存，避免每次答题都查询 MySQL
+type seasonCache struct {
+	mu       sync.Mutex
+	season   *model.Season
+	loadedAt time.Time
+}
+
+// seasonKey 返回指定范围的赛季排行榜 key，如 rank:class:3:season:12
+func seasonKey(scope RankScope, seasonID uint) string {
+	return fmt.Sprintf("%s:%s:%d", scope.keyPrefix(), PeriodSeason, seasonID)
+}
+
+// CurrentSeason 返回 now 所在的赛季，没有覆盖 now 的赛季时自动创建一个到月底结束的赛季
+func (r *Redis) CurrentSeason(now time.Time) (*model.Season, error) {
+	r.seasons.mu.Lock()
+	defer r.seasons.mu.Unlock()
+
+	if season := r.seasons.season; season != nil && !now.Before(season.StartsAt) && now.Before(season.EndsAt) &&
+		time.Since(r.seasons.loadedAt) < seasonCacheTTL {
+		return season, nil
+	}
+
+	season, err := findOrCreateSeason(now)
+	if err != nil {
+		return nil, err
+	}
+	r.seasons.season = season
+	r.seasons.loadedAt = time.Now()
+	return season, nil
+}
+
+// findOrCreateSeason 查询 now 所在的赛季，不存在时创建自动赛季：
+// 从本月初（或上一赛季结束时）开始，到下月初（或下一赛季开始时）结束
+func findOrCreateSeason(now time.Time) (*model.Season, error) {
+	var season model.Season
+	err := database.DB.Where("starts_at <= ? AND ends_at > ?", now, now).First(&season).Error
+	if err == nil {
+		return &season, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("获取赛季失败: %v", err)
+	}
+
+	startsAt := bucketStart(PeriodMonthly, now)
+	endsAt := bucketEnd(PeriodMonthly, now)
+
+	var previous model.Season
+	if err := database.DB.Where("ends_at <= ? AND ends_at > ?", now, startsAt).Order("ends_at DESC").First(&previous).Error; err == nil {
+		startsAt = previous.EndsAt
+	}
+	var next model.Season
+	if err := database.DB.Where("starts_at > ? AND starts_at < ?", now, endsAt).Order("starts_at").First(&next).Error; err == nil {
+		endsAt = next.StartsAt
+	}
+
+	season = model.Season{
+		Name:     fmt.Sprintf("%d年%d月", startsAt.Year(), startsAt.Month()),
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+		Status:   model.SeasonStatusActive,
+		Auto:     true,
+	}
+	if startsAt.Day() != 1 {
+		season.Name = fmt.Sprintf("%s（%d日起）", season.Name, startsAt.Day())
+	}
+
+	// 多个实例同时创建时以先创建的为准
+	if err := database.DB.Where(model.Season{Name: season.Name}).FirstOrCreate(&season).Error; err != nil {
+		return nil, fmt.Errorf("创建赛季失败: %v", err)
+	}
+	return &season, nil
+}
+
+// startSeasonRollover 定期检查已结束的赛季并归档
+func (r *Redis) startSeasonRollover() {
+	ticker := time.NewTicker(seasonRolloverInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := r.ArchiveEndedSeasons(context.Background()); err != nil {
+			fmt.Printf("归档赛季失败: %v\n", err)
+		}
+	}
+}
+
+// ArchiveEndedSeasons 将已结束赛季的最终排名存入 MySQL，并删除 Redis 中的赛季排行榜
+func (r *Redis) ArchiveEndedSeasons(ctx context.Context) error {
+	var seasons []model.Season
+	if err := database.DB.Where("status = ? AND ends_at <= ?", model.SeasonStatusActive, time.Now()).
+		Order("ends_at").Find(&seasons).Error; err != nil {
+		return fmt.Errorf("获取赛季失败: %v", err)
+	}
+
+	for i := range seasons {
+		if err := r.archiveSeason(ctx, &seasons[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveSeason 归档单个赛季，多个实例同时归档时只有一个会成功
+func (r *Redis) archiveSeason(ctx context.Context, season *model.Season) error {
+	scopes, err := allScopes()
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&model.Season{}).
+			Where("id = ? AND status = ?", season.ID, model.SeasonStatusActive).
+			Updates(map[string]interface{}{"status": model.SeasonStatusArchived, "archived_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// 已被其他实例归档
+			return nil
+		}
+
+		for _, scope := range scopes {
+			key := seasonKey(scope, season.ID)
+			keys = append(keys, key)
+
+			result, err := r.Client.ZRevRangeWithScores(ctx, key, 0, SeasonStandingLimit-1).Result()
+			if err != nil {
+				return err
+			}
+			if len(result) == 0 {
+				continue
+			}
+
+			standings := make([]model.SeasonStanding, 0, len(result))
+			for _, item := range r.buildRankingItems(ctx, result, 0) {
+				userID, _ := strconv.ParseUint(item.UserID, 10, 64)
+				standings = append(standings, model.SeasonStanding{
+					SeasonID: season.ID,
+					Scope:    scope.Scope,
+					ScopeID:  scope.ID,
+					UserID:   uint(userID),
+					Username: item.Username,
+					Rank:     item.Rank,
+					Score:    item.HotScore,
+				})
+			}
+			if err := tx.Create(&standings).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("归档赛季 %s 失败: %v", season.Name, err)
+	}
+
+	// 最终排名已保存，删除赛季排行榜
+	if len(keys) > 0 {
+		if err := r.Client.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("删除赛季排行榜失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// allScopes 返回全站以及所有班级、学校的排行榜范围
+func allScopes() ([]RankScope, error) {
+	scopes := []RankScope{GlobalRankScope}
+
+	var classIDs, schoolIDs []uint
+	if err := database.DB.Model(&model.Class{}).Pluck("id", &classIDs).Error; err != nil {
+		return nil, fmt.Errorf("获取班级失败: %v", err)
+	}
+	if err := database.DB.Model(&model.School{}).Pluck("id", &schoolIDs).Error; err != nil {
+		return nil, fmt.Errorf("获取学校失败: %v", err)
+	}
+
+	for _, id := range classIDs {
+		scopes = append(scopes, RankScope{Scope: RankScopeClass, ID: id})
+	}
+	for _, id := range schoolIDs {
+		scopes = append(scopes, RankScope{Scope: RankScopeSchool, ID: id})
+	}
+	return scopes, nil
+}
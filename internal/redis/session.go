@@ -0,0 +1,28 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SessionRevokedKeyPrefix 已吊销会话的 key 前缀：session_revoked:<会话FamilyID>
+const SessionRevokedKeyPrefix = "session_revoked:"
+
+// MarkSessionRevoked 记录会话已吊销，该会话签发的访问令牌随即失效
+// ttl 应为访问令牌的有效期，过期后该会话签发的访问令牌都已过期，不需要再记录
+func (r *Redis) MarkSessionRevoked(ctx context.Context, familyID string, ttl time.Duration) error {
+	if err := r.Client.Set(ctx, SessionRevokedKeyPrefix+familyID, 1, ttl).Err(); err != nil {
+		return fmt.Errorf("记录会话吊销失败: %v", err)
+	}
+	return nil
+}
+
+// SessionRevoked 判断会话是否已吊销
+func (r *Redis) SessionRevoked(ctx context.Context, familyID string) (bool, error) {
+	n, err := r.Client.Exists(ctx, SessionRevokedKeyPrefix+familyID).Result()
+	if err != nil {
+		return false, fmt.Errorf("获取会话吊销状态失败: %v", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,119 @@
+package redis
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// RankSnapshotLockPrefix 每日排名存档的锁，保证每天只存档一次
+	RankSnapshotLockPrefix = "rank_snapshot:"
+	// RankSnapshotRetention 排名存档的保留时间
+	RankSnapshotRetention = 365 * 24 * time.Hour
+	// rankSnapshotInterval 检查是否需要存档的间隔
+	rankSnapshotInterval = time.Hour
+	// rankSnapshotBatchSize 每次从 Redis 读取和写入 MySQL 的条数
+	rankSnapshotBatchSize = 1000
+)
+
+// SnapshotPeriods 每日存档排名的排行榜
+var SnapshotPeriods = []string{PeriodHot, PeriodAll}
+
+// startRankSnapshots 定期存档排名
+func (r *Redis) startRankSnapshots() {
+	ticker := time.NewTicker(rankSnapshotInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := r.SnapshotRanks(context.Background(), time.Now()); err != nil {
+			fmt.Printf("存档排名失败: %v\n", err)
+		}
+	}
+}
+
+// SnapshotRanks 将所有范围下 SnapshotPeriods 排行榜的当前排名存入 MySQL，每天只存档一次
+func (r *Redis) SnapshotRanks(ctx context.Context, now time.Time) error {
+	lockKey := RankSnapshotLockPrefix + bucketID(PeriodDaily, now)
+	acquired, err := r.Client.SetNX(ctx, lockKey, 1, 48*time.Hour).Result()
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		// 今天已经存档（或其他实例正在存档）
+		return nil
+	}
+
+	if err := r.snapshotRanks(ctx, now); err != nil {
+		// 释放锁，下次重试；已写入的记录会被唯一索引去重
+		r.Client.Del(ctx, lockKey)
+		return err
+	}
+
+	cutoff := now.Add(-RankSnapshotRetention)
+	if err := database.DB.Where("date < ?", cutoff).Delete(&model.RankSnapshot{}).Error; err != nil {
+		return fmt.Errorf("清理过期排名存档失败: %v", err)
+	}
+	return nil
+}
+
+// snapshotRanks 逐个排行榜分批读取排名并写入 MySQL
+func (r *Redis) snapshotRanks(ctx context.Context, now time.Time) error {
+	scopes, err := allScopes()
+	if err != nil {
+		return err
+	}
+
+	date := bucketStart(PeriodDaily, now)
+	for _, scope := range scopes {
+		for _, period := range SnapshotPeriods {
+			key, err := r.rankKeyForRead(ctx, scope, period, now)
+			if err != nil {
+				return err
+			}
+
+			for offset := int64(0); ; offset += rankSnapshotBatchSize {
+				result, err := r.Client.ZRevRangeWithScores(ctx, key, offset, offset+rankSnapshotBatchSize-1).Result()
+				if err != nil {
+					return fmt.Errorf("读取排行榜失败: %v", err)
+				}
+				if len(result) == 0 {
+					break
+				}
+				result = displayScores(period, result, now)
+
+				snapshots := make([]model.RankSnapshot, 0, len(result))
+				for i, item := range result {
+					userID, err := strconv.ParseUint(item.Member.(string), 10, 64)
+					if err != nil {
+						continue
+					}
+					snapshots = append(snapshots, model.RankSnapshot{
+						UserID:  uint(userID),
+						Period:  period,
+						Scope:   scope.Scope,
+						ScopeID: scope.ID,
+						Date:    date,
+						Rank:    int(offset) + i + 1,
+						Score:   item.Score,
+					})
+				}
+				if len(snapshots) > 0 {
+					if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&snapshots).Error; err != nil {
+						return fmt.Errorf("保存排名存档失败: %v", err)
+					}
+				}
+
+				if len(result) < rankSnapshotBatchSize {
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
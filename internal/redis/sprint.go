@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 冲刺排行榜 key 前缀，按难度区分
+	SprintRankKeyPrefix = "rank:sprint:"
+)
+
+// setIfHigherScript 仅当新成绩高于历史最好成绩时更新有序集合
+var setIfHigherScript = redis.NewScript(`
+local current = redis.call("ZSCORE", KEYS[1], ARGV[2])
+if current == false or tonumber(ARGV[1]) > tonumber(current) then
+	redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// SprintRankKey 返回指定难度的冲刺排行榜 key
+func SprintRankKey(difficulty string) string {
+	return SprintRankKeyPrefix + difficulty
+}
+
+// UpdateSprintBest 记录用户的冲刺成绩，排行榜只保留个人最好成绩
+func (r *Redis) UpdateSprintBest(ctx context.Context, difficulty string, userID uint, score float64) error {
+	err := setIfHigherScript.Run(ctx, r.Client,
+		[]string{SprintRankKey(difficulty)},
+		score, fmt.Sprintf("%d", userID),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("更新冲刺排行榜失败: %v", err)
+	}
+	return nil
+}
+
+// GetSprintRanking 获取指定难度的冲刺排行榜
+func (r *Redis) GetSprintRanking(ctx context.Context, difficulty string, limit int64) ([]RankingItem, error) {
+	result, err := r.Client.ZRevRangeWithScores(ctx, SprintRankKey(difficulty), 0, limit-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("获取冲刺排行榜失败: %v", err)
+	}
+
+	return r.buildRankingItems(ctx, result, 0), nil
+}
@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// RankUpdateChannel 排行榜变化的发布订阅频道，用于通知所有实例推送最新排行榜
+	RankUpdateChannel = "rank:updates"
+	// StreamTicketKeyPrefix 实时推送票据的 key 前缀
+	StreamTicketKeyPrefix = "stream_ticket:"
+	// StreamTicketTTL 实时推送票据的有效期，客户端取得票据后应立即建立连接
+	StreamTicketTTL = 30 * time.Second
+)
+
+// StreamTicket 实时推送的一次性票据，代替访问令牌出现在 EventSource 的 URL 中，
+// 避免访问令牌被写入访问日志
+type StreamTicket struct {
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"session_id"` // 访问令牌所属的会话，推送期间会话被吊销时断开连接
+}
+
+// SaveStreamTicket 保存实时推送票据
+func (r *Redis) SaveStreamTicket(ctx context.Context, id string, ticket StreamTicket) error {
+	payload, err := json.Marshal(ticket)
+	if err != nil {
+		return err
+	}
+	if err := r.Client.Set(ctx, StreamTicketKeyPrefix+id, payload, StreamTicketTTL).Err(); err != nil {
+		return fmt.Errorf("保存推送票据失败: %v", err)
+	}
+	return nil
+}
+
+// ConsumeStreamTicket 读取并删除实时推送票据，每张票据只能使用一次，不存在或已过期时返回 redis.Nil
+func (r *Redis) ConsumeStreamTicket(ctx context.Context, id string) (*StreamTicket, error) {
+	key := StreamTicketKeyPrefix + id
+	var get *redis.StringCmd
+	if _, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var ticket StreamTicket
+	if err := json.Unmarshal([]byte(get.Val()), &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// RankUpdate 排行榜变化通知，Scopes 为分数发生变化的范围
+type RankUpdate struct {
+	Scopes []RankScope `json:"scopes"`
+}
+
+// publishRankUpdate 发布排行榜变化通知
+func (r *Redis) publishRankUpdate(ctx context.Context, scopes []RankScope) error {
+	payload, err := json.Marshal(RankUpdate{Scopes: scopes})
+	if err != nil {
+		return err
+	}
+	if err := r.Client.Publish(ctx, RankUpdateChannel, payload).Err(); err != nil {
+		return fmt.Errorf("发布排行榜变化失败: %v", err)
+	}
+	return nil
+}
+
+// SubscribeRankUpdates 订阅排行榜变化通知，ctx 结束时关闭订阅和返回的 channel
+func (r *Redis) SubscribeRankUpdates(ctx context.Context) <-chan RankUpdate {
+	pubsub := r.Client.Subscribe(ctx, RankUpdateChannel)
+	updates := make(chan RankUpdate)
+
+	go func() {
+		defer close(updates)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var update RankUpdate
+				if err := json.Unmarshal([]byte(msg.Payload), &update); err != nil {
+					fmt.Printf("解析排行榜变化失败: %v\n", err)
+					continue
+				}
+				select {
+				case updates <- update:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return updates
+}
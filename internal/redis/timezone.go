@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// TimeZoneCacheKey 用户时区缓存，hash 结构：用户ID -> 时区名
+	TimeZoneCacheKey = "user:time_zones"
+	// TimeZoneCacheTTL 缓存整体过期时间
+	TimeZoneCacheTTL = 24 * time.Hour
+)
+
+// LoadTimeZone 解析用户时区，为空时返回服务器时区
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// UserLocation 返回用户设置的时区：先查 Redis 缓存，未命中时查询 MySQL 并回填
+// 查询失败或时区无效时使用服务器时区
+func (r *Redis) UserLocation(ctx context.Context, userID uint) *time.Location {
+	field := fmt.Sprintf("%d", userID)
+
+	name, err := r.Client.HGet(ctx, TimeZoneCacheKey, field).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			fmt.Printf("获取时区缓存失败: %v\n", err)
+		}
+
+		var user model.User
+		if err := database.DB.Select("id", "time_zone").First(&user, userID).Error; err != nil {
+			fmt.Printf("获取用户时区失败: %v\n", err)
+			return time.Local
+		}
+		name = user.TimeZone
+
+		if err := r.cacheFields(ctx, TimeZoneCacheKey, map[string]string{field: name}, TimeZoneCacheTTL); err != nil {
+			fmt.Printf("缓存用户时区失败: %v\n", err)
+		}
+	}
+
+	loc, err := LoadTimeZone(name)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// InvalidateTimeZone 用户修改时区后清除其时区缓存，并按新时区重建每日练习记录
+func (r *Redis) InvalidateTimeZone(ctx context.Context, userID uint) error {
+	if err := r.Client.HDel(ctx, TimeZoneCacheKey, fmt.Sprintf("%d", userID)).Err(); err != nil {
+		return fmt.Errorf("清除时区缓存失败: %v", err)
+	}
+
+	// 每日练习记录按旧时区划分日期，删除后从 MySQL 重建
+	if err := r.Client.Del(ctx, activityKey(userID), activityCountKey(userID)).Err(); err != nil {
+		return fmt.Errorf("清除每日练习记录失败: %v", err)
+	}
+	return r.backfillActivity(ctx, userID, r.UserLocation(ctx, userID))
+}
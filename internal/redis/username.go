@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"calculator/internal/database"
+	"calculator/internal/model"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// UsernameCacheKey 用户名缓存，hash 结构：用户ID -> 用户名
+	UsernameCacheKey = "user:names"
+	// UsernameCacheTTL 缓存从首次写入起的过期时间，兜底清理已删除的用户
+	UsernameCacheTTL = 24 * time.Hour
+)
+
+// cacheFieldsScript 写入 hash 缓存的字段，只在 key 没有过期时间时设置，
+// 使整个缓存从首次写入起按固定时间过期，而不会因为不断回填而永不过期
+// KEYS[1] 缓存 key，ARGV[1] 过期时间（秒），ARGV[2...] 字段和值
+var cacheFieldsScript = redis.NewScript(`
+redis.call("HSET", KEYS[1], unpack(ARGV, 2))
+if redis.call("TTL", KEYS[1]) < 0 then
+	redis.call("EXPIRE", KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// cacheFields 写入 hash 缓存的字段，缓存整体在首次写入 ttl 后过期
+func (r *Redis) cacheFields(ctx context.Context, key string, fields map[string]string, ttl time.Duration) error {
+	args := make([]interface{}, 0, 1+2*len(fields))
+	args = append(args, int64(ttl.Seconds()))
+	for field, value := range fields {
+		args = append(args, field, value)
+	}
+	return cacheFieldsScript.Run(ctx, r.Client, []string{key}, args...).Err()
+}
+
+// resolveUsernames 批量获取用户名：先查 Redis 缓存，未命中的用户通过一次 MySQL 查询获取并回填缓存
+// 缓存不可用时全部从 MySQL 查询，找不到的用户不在返回结果中
+func (r *Redis) resolveUsernames(ctx context.Context, userIDs []string) map[string]string {
+	names := make(map[string]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return names
+	}
+
+	missing := userIDs
+	if cached, err := r.Client.HMGet(ctx, UsernameCacheKey, userIDs...).Result(); err == nil {
+		missing = nil
+		for i, value := range cached {
+			if name, ok := value.(string); ok {
+				names[userIDs[i]] = name
+			} else {
+				missing = append(missing, userIDs[i])
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return names
+	}
+
+	var users []model.User
+	if err := database.DB.Select("id", "username").Where("id IN ?", missing).Find(&users).Error; err != nil {
+		fmt.Printf("获取用户名失败: %v\n", err)
+		return names
+	}
+	if len(users) == 0 {
+		return names
+	}
+
+	fields := make(map[string]string, len(users))
+	for _, user := range users {
+		userID := fmt.Sprintf("%d", user.ID)
+		names[userID] = user.Username
+		fields[userID] = user.Username
+	}
+
+	if err := r.cacheFields(ctx, UsernameCacheKey, fields, UsernameCacheTTL); err != nil {
+		// 回填失败不影响本次结果
+		fmt.Printf("缓存用户名失败: %v\n", err)
+	}
+
+	return names
+}
@@ -0,0 +1,182 @@
+package roster
+
+import (
+	"calculator/internal/model"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxRows 单次导入的最大行数
+	MaxRows = 500
+	// pinLength 自动生成的初始 PIN 位数
+	pinLength = 6
+	// minPasswordLength 初始密码最小长度
+	minPasswordLength = 6
+)
+
+// RowError 某一行的校验错误
+type RowError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// Slip 学生登录凭证条
+type Slip struct {
+	Name      string `json:"name"`
+	StudentNo string `json:"student_no"`
+	ClassName string `json:"class_name"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	Generated bool   `json:"generated"` // 密码是否为自动生成的 PIN
+}
+
+// Validate 校验名单中的每一行，不访问数据库
+func Validate(rows []Row) []RowError {
+	var errs []RowError
+	if len(rows) > MaxRows {
+		return []RowError{{Line: 0, Message: fmt.Sprintf("单次最多导入 %d 名学生", MaxRows)}}
+	}
+
+	seen := make(map[string]int, len(rows))
+	for _, row := range rows {
+		switch {
+		case row.Name == "":
+			errs = append(errs, RowError{Line: row.Line, Message: "姓名不能为空"})
+		case utf8.RuneCountInString(row.Name) > 50:
+			errs = append(errs, RowError{Line: row.Line, Message: "姓名过长"})
+		}
+
+		switch {
+		case row.StudentNo == "":
+			errs = append(errs, RowError{Line: row.Line, Message: "学号不能为空"})
+		case len(row.StudentNo) > 50:
+			errs = append(errs, RowError{Line: row.Line, Message: "学号过长"})
+		default:
+			if first, ok := seen[row.StudentNo]; ok {
+				errs = append(errs, RowError{Line: row.Line, Message: fmt.Sprintf("学号与第 %d 行重复", first)})
+			} else {
+				seen[row.StudentNo] = row.Line
+			}
+		}
+
+		if utf8.RuneCountInString(row.ClassName) > 50 {
+			errs = append(errs, RowError{Line: row.Line, Message: "班级名称过长"})
+		}
+
+		if row.Password != "" && len(row.Password) < minPasswordLength {
+			errs = append(errs, RowError{Line: row.Line, Message: fmt.Sprintf("初始密码至少 %d 位", minPasswordLength)})
+		}
+	}
+	return errs
+}
+
+// Import 在一个事务中为名单创建学生账号并加入教师的班级（班级不存在时自动创建）
+// 任意一行校验失败时不会创建任何账号，返回所有行的错误
+func Import(db *gorm.DB, teacherID uint, rows []Row) ([]Slip, []RowError, error) {
+	if rowErrs := Validate(rows); len(rowErrs) > 0 {
+		return nil, rowErrs, nil
+	}
+
+	// 检查学号是否已被注册
+	studentNos := make([]string, 0, len(rows))
+	for _, row := range rows {
+		studentNos = append(studentNos, row.StudentNo)
+	}
+	var existing []string
+	if err := db.Model(&model.User{}).Where("username IN ?", studentNos).Pluck("username", &existing).Error; err != nil {
+		return nil, nil, fmt.Errorf("检查学号失败: %v", err)
+	}
+	if len(existing) > 0 {
+		taken := make(map[string]bool, len(existing))
+		for _, username := range existing {
+			taken[username] = true
+		}
+		var rowErrs []RowError
+		for _, row := range rows {
+			if taken[row.StudentNo] {
+				rowErrs = append(rowErrs, RowError{Line: row.Line, Message: "该学号已存在账号"})
+			}
+		}
+		return nil, rowErrs, nil
+	}
+
+	slips := make([]Slip, 0, len(rows))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		classes := make(map[string]uint)
+		for _, row := range rows {
+			slip := Slip{
+				Name:      row.Name,
+				StudentNo: row.StudentNo,
+				ClassName: row.ClassName,
+				Username:  row.StudentNo,
+				Password:  row.Password,
+			}
+			if slip.Password == "" {
+				pin, err := GeneratePIN()
+				if err != nil {
+					return err
+				}
+				slip.Password = pin
+				slip.Generated = true
+			}
+
+			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(slip.Password), bcrypt.DefaultCost)
+			if err != nil {
+				return fmt.Errorf("第 %d 行密码加密失败: %v", row.Line, err)
+			}
+
+			user := model.User{
+				Username:  slip.Username,
+				Password:  string(hashedPassword),
+				Role:      model.RoleStudent,
+				Name:      row.Name,
+				StudentNo: row.StudentNo,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return fmt.Errorf("第 %d 行创建账号失败: %v", row.Line, err)
+			}
+
+			if row.ClassName != "" {
+				classID, ok := classes[row.ClassName]
+				if !ok {
+					class := model.Class{TeacherID: teacherID, Name: row.ClassName}
+					if err := tx.Where(class).FirstOrCreate(&class).Error; err != nil {
+						return fmt.Errorf("第 %d 行创建班级失败: %v", row.Line, err)
+					}
+					classID = class.ID
+					classes[row.ClassName] = classID
+				}
+				if err := tx.Create(&model.Enrollment{ClassID: classID, StudentID: user.ID}).Error; err != nil {
+					return fmt.Errorf("第 %d 行加入班级失败: %v", row.Line, err)
+				}
+			}
+
+			slips = append(slips, slip)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return slips, nil, nil
+}
+
+// GeneratePIN 生成随机数字 PIN
+func GeneratePIN() (string, error) {
+	pin := make([]byte, pinLength)
+	for i := range pin {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", fmt.Errorf("生成 PIN 失败: %v", err)
+		}
+		pin[i] = byte('0' + n.Int64())
+	}
+	return string(pin), nil
+}
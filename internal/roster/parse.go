@@ -0,0 +1,245 @@
+package roster
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Row 名单中的一行
+type Row struct {
+	Line      int    // 文件中的行号（从1开始，含表头）
+	Name      string // 学生姓名
+	StudentNo string // 学号，同时作为登录用户名
+	ClassName string // 班级名称
+	Password  string // 初始密码，为空时自动生成 PIN
+}
+
+// 表头别名，支持中英文
+var headerAliases = map[string]string{
+	"姓名":         "name",
+	"name":       "name",
+	"学号":         "student_no",
+	"student_no": "student_no",
+	"student no": "student_no",
+	"班级":         "class",
+	"class":      "class",
+	"密码":         "password",
+	"初始密码":       "password",
+	"password":   "password",
+}
+
+// Parse 根据文件扩展名解析 CSV 或 XLSX 名单
+func Parse(filename string, data []byte) ([]Row, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return ParseCSV(bytes.NewReader(data))
+	case ".xlsx":
+		return ParseXLSX(bytes.NewReader(data), int64(len(data)))
+	default:
+		return nil, fmt.Errorf("不支持的文件格式: %s", filename)
+	}
+}
+
+// ParseCSV 解析 CSV 名单，第一行必须为表头
+func ParseCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析 CSV 失败: %v", err)
+	}
+	return rowsFromTable(records)
+}
+
+// ParseXLSX 解析 XLSX 名单（读取第一个工作表），第一行必须为表头
+func ParseXLSX(r io.ReaderAt, size int64) ([]Row, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("解析 XLSX 失败: %v", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	var sheets []string
+	for _, f := range zr.File {
+		files[f.Name] = f
+		if strings.HasPrefix(f.Name, "xl/worksheets/") && strings.HasSuffix(f.Name, ".xml") {
+			sheets = append(sheets, f.Name)
+		}
+	}
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("XLSX 中没有工作表")
+	}
+
+	sheetName := "xl/worksheets/sheet1.xml"
+	if files[sheetName] == nil {
+		sort.Strings(sheets)
+		sheetName = sheets[0]
+	}
+
+	var sharedStrings []string
+	if f := files["xl/sharedStrings.xml"]; f != nil {
+		if sharedStrings, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	table, err := readSheet(files[sheetName], sharedStrings)
+	if err != nil {
+		return nil, err
+	}
+	return rowsFromTable(table)
+}
+
+// rowsFromTable 根据表头把二维表转换为名单行，跳过空行
+func rowsFromTable(table [][]string) ([]Row, error) {
+	if len(table) == 0 {
+		return nil, fmt.Errorf("名单为空")
+	}
+
+	columns := make(map[string]int)
+	for i, header := range table[0] {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, "\ufeff")))
+		if field, ok := headerAliases[key]; ok {
+			columns[field] = i
+		}
+	}
+	for _, required := range []string{"name", "student_no"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("名单缺少必需的列: %s", required)
+		}
+	}
+
+	cell := func(record []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]Row, 0, len(table)-1)
+	for i, record := range table[1:] {
+		row := Row{
+			Line:      i + 2,
+			Name:      cell(record, "name"),
+			StudentNo: cell(record, "student_no"),
+			ClassName: cell(record, "class"),
+			Password:  cell(record, "password"),
+		}
+		if row.Name == "" && row.StudentNo == "" && row.ClassName == "" && row.Password == "" {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// readSharedStrings 读取 XLSX 的共享字符串表
+func readSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取共享字符串失败: %v", err)
+	}
+	defer rc.Close()
+
+	var sst struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := xml.NewDecoder(rc).Decode(&sst); err != nil {
+		return nil, fmt.Errorf("解析共享字符串失败: %v", err)
+	}
+
+	strs := make([]string, 0, len(sst.Items))
+	for _, item := range sst.Items {
+		text := item.Text
+		for _, run := range item.Runs {
+			text += run.Text
+		}
+		strs = append(strs, text)
+	}
+	return strs, nil
+}
+
+// readSheet 读取工作表为二维表
+func readSheet(f *zip.File, sharedStrings []string) ([][]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取工作表失败: %v", err)
+	}
+	defer rc.Close()
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.NewDecoder(rc).Decode(&sheet); err != nil {
+		return nil, fmt.Errorf("解析工作表失败: %v", err)
+	}
+
+	table := make([][]string, 0, len(sheet.Rows))
+	for _, row := range sheet.Rows {
+		var record []string
+		for i, c := range row.Cells {
+			col := columnIndex(c.Ref)
+			if col < 0 {
+				col = i
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(sharedStrings) {
+					return nil, fmt.Errorf("单元格 %s 的共享字符串索引无效", c.Ref)
+				}
+				record[col] = sharedStrings[idx]
+			case "inlineStr":
+				record[col] = c.Inline
+			default:
+				record[col] = c.Value
+			}
+		}
+		table = append(table, record)
+	}
+	return table, nil
+}
+
+// columnIndex 将单元格引用（如 "C12"）转换为从0开始的列号
+func columnIndex(ref string) int {
+	col := 0
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
@@ -0,0 +1,89 @@
+package roster
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	data := "姓名,学号,班级,密码\n张三,2024001,二年级一班,\n李四,2024002,二年级一班,abc123\n,,,\n"
+
+	rows, err := ParseCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("行数不正确, 期望 %d, 实际 %d", 2, len(rows))
+	}
+
+	want := Row{Line: 3, Name: "李四", StudentNo: "2024002", ClassName: "二年级一班", Password: "abc123"}
+	if rows[1] != want {
+		t.Errorf("解析结果不正确, 期望 %+v, 实际 %+v", want, rows[1])
+	}
+}
+
+func TestParseCSV_MissingColumn(t *testing.T) {
+	if _, err := ParseCSV(strings.NewReader("姓名,班级\n张三,一班\n")); err == nil {
+		t.Errorf("缺少学号列时应返回错误")
+	}
+}
+
+func TestParseXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>name</t></si><si><t>student_no</t></si><si><r><t>王</t></r><r><t>五</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>2024003</v></c></row>` +
+			`<row r="3"><c r="A3" t="inlineStr"><is><t>赵六</t></is></c><c r="B3" t="inlineStr"><is><t>2024004</t></is></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	rows, err := Parse("roster.xlsx", buf.Bytes())
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("行数不正确, 期望 %d, 实际 %d", 2, len(rows))
+	}
+	if rows[0].Name != "王五" || rows[0].StudentNo != "2024003" {
+		t.Errorf("第一行解析不正确: %+v", rows[0])
+	}
+	if rows[1].Name != "赵六" || rows[1].StudentNo != "2024004" {
+		t.Errorf("第二行解析不正确: %+v", rows[1])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	rows := []Row{
+		{Line: 2, Name: "张三", StudentNo: "1"},
+		{Line: 3, Name: "", StudentNo: "2"},
+		{Line: 4, Name: "李四", StudentNo: "1"},
+		{Line: 5, Name: "王五", StudentNo: "3", Password: "123"},
+	}
+
+	errs := Validate(rows)
+	lines := make(map[int]bool)
+	for _, e := range errs {
+		lines[e.Line] = true
+	}
+	for _, line := range []int{3, 4, 5} {
+		if !lines[line] {
+			t.Errorf("第 %d 行应有校验错误", line)
+		}
+	}
+	if lines[2] {
+		t.Errorf("第 2 行不应有校验错误")
+	}
+}
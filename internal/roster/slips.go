@@ -0,0 +1,36 @@
+package roster
+
+import (
+	"html/template"
+	"io"
+)
+
+// slipsTemplate 可打印的凭证条页面，每张凭证条可沿虚线剪开分发
+var slipsTemplate = template.Must(template.New("slips").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="UTF-8">
+<title>学生登录凭证</title>
+<style>
+body { font-family: sans-serif; margin: 0; padding: 16px; }
+.slip { display: inline-block; width: 45%; margin: 8px; padding: 12px; border: 1px dashed #999; box-sizing: border-box; page-break-inside: avoid; }
+.slip h3 { margin: 0 0 8px; }
+.slip p { margin: 4px 0; }
+.password { font-family: monospace; font-size: 1.2em; }
+</style>
+</head>
+<body>
+{{range .}}<div class="slip">
+<h3>{{.Name}}{{if .ClassName}}（{{.ClassName}}）{{end}}</h3>
+<p>学号：{{.StudentNo}}</p>
+<p>用户名：{{.Username}}</p>
+<p>初始密码：<span class="password">{{.Password}}</span></p>
+</div>
+{{end}}</body>
+</html>
+`))
+
+// RenderSlips 将凭证条渲染为可打印的 HTML 页面
+func RenderSlips(w io.Writer, slips []Slip) error {
+	return slipsTemplate.Execute(w, slips)
+}
@@ -3,13 +3,30 @@ package router
 import (
 	"calculator/internal/handlers"
 	"calculator/internal/middleware"
+	"calculator/internal/model"
+	"calculator/internal/redis"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRouter 设置所有路由
-func SetupRouter() *gin.Engine {
+// 各路由组的限流规则
+var (
+	// 登录：每个 IP 每 6 秒 1 次，最多连续 5 次
+	loginRateLimit = middleware.RateLimitRule{Name: "login", Rate: 1.0 / 6, Burst: 5}
+	// 注册：每个 IP 每分钟 1 次，最多连续 3 次
+	registerRateLimit = middleware.RateLimitRule{Name: "register", Rate: 1.0 / 60, Burst: 3}
+	// 刷新令牌：每个 IP 每秒 1 次，最多连续 20 次（同一网络下的多个客户端共用 IP）
+	refreshRateLimit = middleware.RateLimitRule{Name: "refresh", Rate: 1, Burst: 20}
+	// 提交答案：每个用户每秒 2 次，最多连续 10 次
+	answerRateLimit = middleware.RateLimitRule{Name: "answer", Rate: 2, Burst: 10}
+)
+
+// SetupRouter 设置所有路由，redisClient 为 nil 时限流使用单机内存模式
+func SetupRouter(redisClient *redis.Redis) *gin.Engine {
 	r := gin.Default()
+	limiter := middleware.NewLimiter(redisClient)
+	// 已登出或被吊销的会话签发的访问令牌立即失效
+	middleware.SetSessionRevocationCheck(handlers.SessionRevoked)
 
 	// 允许跨域
 	r.Use(middleware.CORS())
@@ -26,26 +43,184 @@ func SetupRouter() *gin.Engine {
 	r.StaticFile("/history-detail.html", "./frontend/history-detail.html")
 	r.StaticFile("/history-detail.js", "./frontend/history-detail.js")
 
+	// 访问令牌公钥，供其他服务验证 token
+	r.GET("/.well-known/jwks.json", handlers.GetJWKS)
+
 	// API 路由组
 	api := r.Group("/api")
 	{
 		// 认证相关路由
 		auth := api.Group("/auth")
 		{
-			auth.POST("/register", handlers.Register)
-			auth.POST("/login", handlers.Login)
+			auth.POST("/register", middleware.RateLimit(limiter, registerRateLimit), handlers.Register)
+			auth.POST("/login", middleware.RateLimit(limiter, loginRateLimit), handlers.Login)
+			auth.POST("/refresh", middleware.RateLimit(limiter, refreshRateLimit), handlers.RefreshToken)
 			auth.POST("/logout", handlers.Logout)
 		}
 
+		// 个人资料
+		profile := api.Group("/profile")
+		profile.Use(middleware.AuthRequired())
+		{
+			profile.GET("", handlers.GetProfile)
+			profile.PUT("", handlers.UpdateProfile)
+		}
+
 		// 题目相关路由
 		drill := api.Group("/drill")
 		drill.Use(middleware.AuthRequired())
 		{
 			drill.GET("/question", handlers.GetQuestion)
-			drill.POST("/answer", handlers.SubmitAnswer)
+			drill.POST("/answer", middleware.RateLimit(limiter, answerRateLimit), handlers.SubmitAnswer)
 			drill.GET("/rankings", handlers.GetHotRanking)
+			drill.GET("/rankings/me", handlers.GetMyRank)
+			drill.GET("/rankings/me/history", handlers.GetMyRankHistory)
+			drill.POST("/rankings/stream/ticket", handlers.CreateStreamTicket)
+		}
+		// 排行榜实时推送（SSE），EventSource 无法设置请求头，通过 ticket 参数传递一次性票据
+		api.GET("/drill/rankings/stream", handlers.StreamAuth(), handlers.StreamRankings)
+
+		// 练习会话相关路由
+		practice := api.Group("/practice")
+		practice.Use(middleware.AuthRequired())
+		{
+			practice.POST("/sessions", handlers.StartPractice)
+			practice.GET("/sessions", handlers.GetPracticeSessions)
+			practice.GET("/sessions/:id", handlers.GetPracticeSession)
+			practice.GET("/sessions/:id/question", handlers.GetPracticeQuestion)
+			practice.POST("/sessions/:id/answer", middleware.RateLimit(limiter, answerRateLimit), handlers.SubmitPracticeAnswer)
+			practice.POST("/sessions/:id/finish", handlers.FinishPractice)
+		}
+
+		// 限时冲刺相关路由
+		sprint := api.Group("/sprint")
+		sprint.Use(middleware.AuthRequired())
+		{
+			sprint.POST("", handlers.StartSprint)
+			sprint.GET("/rankings", handlers.GetSprintRanking)
+			sprint.GET("/:id", handlers.GetSprint)
+			sprint.POST("/:id/answer", middleware.RateLimit(limiter, answerRateLimit), handlers.SubmitSprintAnswer)
 		}
 
+		// 考试相关路由
+		exams := api.Group("/exams")
+		exams.Use(middleware.AuthRequired())
+		{
+			exams.POST("", middleware.RoleMiddleware(model.RoleTeacher), handlers.CreateExamPaper)
+			exams.GET("", handlers.GetExamPapers)
+			exams.GET("/:id", handlers.GetExamPaper)
+			exams.GET("/:id/attempts", middleware.RoleMiddleware(model.RoleTeacher), handlers.GetExamAttempts)
+			exams.POST("/:id/attempts", handlers.StartExam)
+		}
+
+		examAttempts := api.Group("/exam-attempts")
+		examAttempts.Use(middleware.AuthRequired())
+		{
+			examAttempts.GET("/:id", handlers.GetExamAttempt)
+			examAttempts.PUT("/:id/answers", handlers.SaveExamAnswers)
+			examAttempts.POST("/:id/submit", handlers.SubmitExam)
+			examAttempts.POST("/:id/allow-retake", middleware.RoleMiddleware(model.RoleTeacher), handlers.AllowExamRetake)
+		}
+
+		// 班级管理相关路由（仅教师）
+		classes := api.Group("/classes")
+		classes.Use(middleware.AuthRequired(), middleware.RoleMiddleware(model.RoleTeacher))
+		{
+			classes.POST("", handlers.CreateClass)
+			classes.GET("", handlers.GetClasses)
+			classes.POST("/import", handlers.ImportRoster)
+			classes.GET("/:id", handlers.GetClass)
+			classes.POST("/:id/join-code", handlers.ResetClassJoinCode)
+			classes.POST("/:id/students", handlers.AddClassStudent)
+			classes.DELETE("/:id/students/:student_id", handlers.RemoveClassStudent)
+			classes.GET("/:id/students/:student_id/history", handlers.GetClassStudentHistory)
+			classes.GET("/:id/students/:student_id/stats", handlers.GetClassStudentStats)
+			classes.POST("/:id/students/:student_id/link-code", handlers.CreateClassStudentLinkCode)
+			classes.GET("/:id/students/:student_id/goal", handlers.GetClassStudentGoal)
+			classes.PUT("/:id/students/:student_id/goal", handlers.SetClassStudentGoal)
+			classes.GET("/:id/students/:student_id/coins", handlers.GetClassStudentCoins)
+			classes.POST("/:id/homework", handlers.CreateHomework)
+			classes.GET("/:id/homework", handlers.GetClassHomework)
+			classes.GET("/:id/homework/:homework_id/report", handlers.GetHomeworkReport)
+		}
+
+		// 学生作业相关路由
+		homework := api.Group("/homework")
+		homework.Use(middleware.AuthRequired())
+		{
+			homework.GET("", handlers.GetMyHomework)
+			homework.POST("/:id/start", handlers.StartHomework)
+		}
+
+		// 家长关联码（学生生成）
+		api.POST("/parent-links/code", middleware.AuthRequired(), middleware.RoleMiddleware(model.RoleStudent), handlers.CreateMyLinkCode)
+
+		// 家长相关路由（只读查看孩子的学习情况）
+		parent := api.Group("/parent")
+		parent.Use(middleware.AuthRequired(), middleware.RoleMiddleware(model.RoleParent))
+		{
+			parent.POST("/children", handlers.LinkChild)
+			parent.GET("/children", handlers.GetChildren)
+			parent.GET("/children/:student_id/history", handlers.GetChildHistory)
+			parent.GET("/children/:student_id/stats", handlers.GetChildStats)
+			parent.GET("/children/:student_id/homework", handlers.GetChildHomework)
+			parent.GET("/children/:student_id/achievements", handlers.GetChildAchievements)
+			parent.GET("/children/:student_id/coins", handlers.GetChildCoins)
+		}
+
+		// 管理员相关路由
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthRequired(), middleware.RoleMiddleware(model.RoleAdmin))
+		{
+			admin.POST("/invites", handlers.CreateInvite)
+			admin.GET("/invites", handlers.GetInvites)
+			admin.POST("/schools", handlers.CreateSchool)
+			admin.PUT("/teachers/:id/school", handlers.SetTeacherSchool)
+			admin.POST("/seasons", handlers.CreateSeason)
+		}
+
+		// 排行榜赛季
+		seasons := api.Group("/seasons")
+		seasons.Use(middleware.AuthRequired())
+		{
+			seasons.GET("", handlers.GetSeasons)
+			seasons.GET("/:id/standings", handlers.GetSeasonStandings)
+		}
+
+		// 成就
+		api.GET("/achievements", middleware.AuthRequired(), handlers.GetAchievements)
+
+		// 每日目标（学生自己设置，也可以由任课教师设置）
+		goals := api.Group("/goals")
+		goals.Use(middleware.AuthRequired())
+		{
+			goals.GET("", handlers.GetGoal)
+			goals.PUT("", middleware.RoleMiddleware(model.RoleStudent), handlers.SetGoal)
+			goals.GET("/history", handlers.GetGoalHistory)
+			goals.POST("/reminder/ack", handlers.AcknowledgeGoalReminder)
+		}
+
+		// 金币和装扮兑换
+		api.GET("/coins", middleware.AuthRequired(), handlers.GetCoins)
+		shop := api.Group("/shop")
+		shop.Use(middleware.AuthRequired())
+		{
+			shop.GET("/items", handlers.GetShopItems)
+			shop.POST("/items/:code/purchase", handlers.PurchaseItem)
+			shop.POST("/items/:code/equip", handlers.EquipItem)
+		}
+
+		// 连续练习天数和练习日历
+		streak := api.Group("/streak")
+		streak.Use(middleware.AuthRequired())
+		{
+			streak.GET("", handlers.GetStreak)
+			streak.GET("/calendar", handlers.GetActivityCalendar)
+		}
+
+		// 学校列表，教师创建班级时选择
+		api.GET("/schools", middleware.AuthRequired(), handlers.GetSchools)
+
 		// 历史记录相关路由
 		history := api.Group("/history")
 		history.Use(middleware.AuthRequired())
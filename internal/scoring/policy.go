@@ -0,0 +1,157 @@
+package scoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// Policy 答题得分规则，实时更新排行榜和重建排行榜使用同一套规则
+type Policy struct {
+	// BasePoints 每次答题的基础分，答错也有
+	BasePoints float64 `json:"base_points"`
+	// CorrectBonus 答对的额外分，速度奖励和连对倍数只作用于这部分
+	CorrectBonus float64 `json:"correct_bonus"`
+	// DifficultyWeights 各难度的分数倍数，未配置的难度为 1
+	DifficultyWeights map[string]float64 `json:"difficulty_weights"`
+	// SpeedThresholds 各难度在多少秒内答对可获得速度奖励，未配置的难度没有速度奖励
+	SpeedThresholds map[string]float64 `json:"speed_thresholds"`
+	// SpeedBonus 速度奖励比例，0.5 表示答对分数增加 50%
+	SpeedBonus float64 `json:"speed_bonus"`
+	// EasyDailyLimit 每天前多少道简单题得全分，之后的简单题得分逐题递减
+	EasyDailyLimit int `json:"easy_daily_limit"`
+	// EasyDecay 超出后每多一道简单题得分乘以的系数
+	EasyDecay float64 `json:"easy_decay"`
+	// EasyMinFactor 简单题得分系数的下限
+	EasyMinFactor float64 `json:"easy_min_factor"`
+	// StreakStep 每多连对一题，答对分数增加的比例
+	StreakStep float64 `json:"streak_step"`
+	// StreakMax 连对倍数上限
+	StreakMax float64 `json:"streak_max"`
+	// StreakTimeout 两次答题间隔超过该时间（秒）时连对中断
+	StreakTimeout int `json:"streak_timeout"`
+}
+
+// DefaultPolicy 默认得分规则
+func DefaultPolicy() Policy {
+	return Policy{
+		BasePoints:        50,
+		CorrectBonus:      100,
+		DifficultyWeights: map[string]float64{"easy": 1, "medium": 2, "hard": 3},
+		SpeedThresholds:   map[string]float64{"easy": 5, "medium": 15, "hard": 40},
+		SpeedBonus:        0.5,
+		EasyDailyLimit:    30,
+		EasyDecay:         0.9,
+		EasyMinFactor:     0.1,
+		StreakStep:        0.1,
+		StreakMax:         2,
+		StreakTimeout:     30 * 60,
+	}
+}
+
+// Answer 参与计分的一次答题
+type Answer struct {
+	Difficulty string
+	Correct    bool
+	TimeSpent  float64 // 秒，0 表示未知
+	At         time.Time
+}
+
+// State 用户的计分状态，按答题时间顺序依次累积
+type State struct {
+	Day       string `json:"day"`     // EasyCount 所属的日期（YYYYMMDD）
+	EasyCount int    `json:"easy"`    // 当天已答的简单题数
+	Streak    int    `json:"streak"`  // 当前连对题数
+	LastAt    int64  `json:"last_at"` // 上一次答题的时间（Unix 秒）
+}
+
+// Score 计算一次答题的得分并更新计分状态，调用方需按答题时间顺序调用
+func (p Policy) Score(state *State, answer Answer) float64 {
+	day := answer.At.Local().Format("20060102")
+	if state.Day != day {
+		state.Day = day
+		state.EasyCount = 0
+	}
+	if state.LastAt != 0 && answer.At.Unix()-state.LastAt > int64(p.StreakTimeout) {
+		state.Streak = 0
+	}
+	state.LastAt = answer.At.Unix()
+
+	weight, ok := p.DifficultyWeights[answer.Difficulty]
+	if !ok {
+		weight = 1
+	}
+
+	// 简单题刷题收益递减
+	factor := 1.0
+	if answer.Difficulty == "easy" {
+		state.EasyCount++
+		if extra := state.EasyCount - p.EasyDailyLimit; extra > 0 {
+			factor = math.Max(p.EasyMinFactor, math.Pow(p.EasyDecay, float64(extra)))
+		}
+	}
+
+	if !answer.Correct {
+		state.Streak = 0
+		return p.BasePoints * weight * factor
+	}
+
+	state.Streak++
+	bonus := p.CorrectBonus
+	if threshold, ok := p.SpeedThresholds[answer.Difficulty]; ok && answer.TimeSpent > 0 && answer.TimeSpent <= threshold {
+		bonus *= 1 + p.SpeedBonus
+	}
+	bonus *= math.Min(1+p.StreakStep*float64(state.Streak-1), math.Max(p.StreakMax, 1))
+
+	return (p.BasePoints + bonus) * weight * factor
+}
+
+// StateTTL 计分状态的保存时间，超过后当天简单题数和连对都已失效
+func (p Policy) StateTTL() time.Duration {
+	ttl := 48 * time.Hour
+	if timeout := time.Duration(p.StreakTimeout) * time.Second; timeout > ttl {
+		ttl = timeout
+	}
+	return ttl
+}
+
+var (
+	currentOnce   sync.Once
+	currentPolicy Policy
+)
+
+// Current 返回当前部署使用的得分规则：
+// 设置环境变量 SCORING_POLICY_FILE 时从该 JSON 文件读取（未出现的字段使用默认值），否则使用默认规则
+func Current() Policy {
+	currentOnce.Do(func() {
+		currentPolicy = DefaultPolicy()
+		path := os.Getenv("SCORING_POLICY_FILE")
+		if path == "" {
+			return
+		}
+		policy, err := Load(path)
+		if err != nil {
+			fmt.Printf("加载得分规则失败，使用默认规则: %v\n", err)
+			return
+		}
+		currentPolicy = policy
+	})
+	return currentPolicy
+}
+
+// Load 从 JSON 文件读取得分规则，未出现的字段使用默认值
+func Load(path string) (Policy, error) {
+	policy := DefaultPolicy()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("解析得分规则失败: %v", err)
+	}
+	return policy, nil
+}
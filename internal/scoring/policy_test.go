@@ -0,0 +1,106 @@
+package scoring
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPolicy_DifficultyAndSpeed(t *testing.T) {
+	p := DefaultPolicy()
+	at := time.Date(2025, 6, 1, 10, 0, 0, 0, time.Local)
+
+	var state State
+	if got := p.Score(&state, Answer{Difficulty: "easy", Correct: false, At: at}); got != 50 {
+		t.Errorf("简单题答错得分不正确, 期望 50, 实际 %v", got)
+	}
+
+	state = State{}
+	if got := p.Score(&state, Answer{Difficulty: "hard", Correct: true, TimeSpent: 60, At: at}); got != 450 {
+		t.Errorf("困难题答对得分不正确, 期望 450, 实际 %v", got)
+	}
+
+	state = State{}
+	if got := p.Score(&state, Answer{Difficulty: "hard", Correct: true, TimeSpent: 30, At: at}); got != 600 {
+		t.Errorf("困难题快速答对得分不正确, 期望 600, 实际 %v", got)
+	}
+}
+
+func TestPolicy_Streak(t *testing.T) {
+	p := DefaultPolicy()
+	at := time.Date(2025, 6, 1, 10, 0, 0, 0, time.Local)
+
+	var state State
+	var scores []float64
+	for i := 0; i < 3; i++ {
+		scores = append(scores, p.Score(&state, Answer{Difficulty: "medium", Correct: true, TimeSpent: 20, At: at.Add(time.Duration(i) * time.Minute)}))
+	}
+	want := []float64{300, 320, 340}
+	for i := range want {
+		if math.Abs(scores[i]-want[i]) > 1e-9 {
+			t.Errorf("第 %d 题连对得分不正确, 期望 %v, 实际 %v", i+1, want[i], scores[i])
+		}
+	}
+
+	// 答错中断连对
+	p.Score(&state, Answer{Difficulty: "medium", Correct: false, At: at.Add(4 * time.Minute)})
+	if got := p.Score(&state, Answer{Difficulty: "medium", Correct: true, TimeSpent: 20, At: at.Add(5 * time.Minute)}); got != 300 {
+		t.Errorf("答错后连对应重新计算, 期望 300, 实际 %v", got)
+	}
+
+	// 长时间未答题中断连对
+	p.Score(&state, Answer{Difficulty: "medium", Correct: true, TimeSpent: 20, At: at.Add(6 * time.Minute)})
+	if got := p.Score(&state, Answer{Difficulty: "medium", Correct: true, TimeSpent: 20, At: at.Add(2 * time.Hour)}); got != 300 {
+		t.Errorf("超时后连对应重新计算, 期望 300, 实际 %v", got)
+	}
+}
+
+func TestPolicy_EasyDiminishingReturns(t *testing.T) {
+	p := DefaultPolicy()
+	p.StreakStep = 0
+	at := time.Date(2025, 6, 1, 10, 0, 0, 0, time.Local)
+
+	var state State
+	var last float64
+	for i := 0; i < p.EasyDailyLimit; i++ {
+		last = p.Score(&state, Answer{Difficulty: "easy", Correct: true, At: at})
+	}
+	if last != 150 {
+		t.Fatalf("每日限额内的简单题应得全分, 实际 %v", last)
+	}
+
+	if got := p.Score(&state, Answer{Difficulty: "easy", Correct: true, At: at}); math.Abs(got-135) > 1e-9 {
+		t.Errorf("超出限额的第一道简单题得分不正确, 期望 135, 实际 %v", got)
+	}
+	for i := 0; i < 100; i++ {
+		last = p.Score(&state, Answer{Difficulty: "easy", Correct: true, At: at})
+	}
+	if math.Abs(last-15) > 1e-9 {
+		t.Errorf("简单题得分不应低于下限, 期望 15, 实际 %v", last)
+	}
+
+	// 第二天重新计算
+	if got := p.Score(&state, Answer{Difficulty: "easy", Correct: true, At: at.Add(24 * time.Hour)}); got != 150 {
+		t.Errorf("第二天的简单题应得全分, 实际 %v", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(`{"base_points": 10, "difficulty_weights": {"hard": 5}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if p.BasePoints != 10 || p.DifficultyWeights["hard"] != 5 {
+		t.Errorf("配置未生效: %+v", p)
+	}
+	if p.CorrectBonus != 100 || p.DifficultyWeights["medium"] != 2 {
+		t.Errorf("未配置的字段应使用默认值: %+v", p)
+	}
+}
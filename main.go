@@ -2,9 +2,12 @@ package main
 
 import (
 	"calculator/internal/database"
+	"calculator/internal/handlers"
+	"calculator/internal/jwtkeys"
 	"calculator/internal/redis"
 	"calculator/internal/router"
 	"log"
+	"os"
 )
 
 func main() {
@@ -13,16 +16,40 @@ func main() {
 		log.Fatalf("数据库初始化失败: %v", err)
 	}
 
-	// 初始化Redis连接
+	// 命令行子命令（如 import-roster）执行完即退出
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatalf("命令执行失败: %v", err)
+		}
+		return
+	}
+
+	// 加载 JWT 签名密钥，配置错误时直接退出
+	if _, err := jwtkeys.Default(); err != nil {
+		log.Fatalf("加载 JWT 签名密钥失败: %v", err)
+	}
+
+	// 初始化Redis连接，handlers 与限流共用同一个客户端
 	redisClient := redis.NewRedis()
+	handlers.UseRedis(redisClient)
 
 	// 初始化排行榜数据
 	if err := redisClient.InitRankingData(); err != nil {
 		log.Printf("初始化排行榜数据失败: %v", err)
 	}
 
-	// 设置路由
-	r := router.SetupRouter()
+	// 启动排行榜定期重建、赛季归档和每日排名存档
+	redisClient.StartBackgroundJobs()
+
+	// 定期结算中途离开的限时冲刺
+	handlers.StartSprintFinalizer()
+
+	// 设置路由（单机部署可设置 RATE_LIMIT_BACKEND=memory 使用内存限流）
+	limiterRedis := redisClient
+	if os.Getenv("RATE_LIMIT_BACKEND") == "memory" {
+		limiterRedis = nil
+	}
+	r := router.SetupRouter(limiterRedis)
 
 	// 启动服务器
 	if err := r.Run(":8080"); err != nil {